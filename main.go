@@ -1,28 +1,14 @@
 package main
 
 import (
-	"archive/tar"
 	"bufio"
-	"bytes"
-	"compress/gzip"
 	"context"
-	"crypto/rand"
-	_ "embed"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/tetratelabs/wazero"
-	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
-	"github.com/tetratelabs/wazero/sys"
-)
-
-var (
-	//go:embed pglite-wasi.tar.gz
-	compressed []byte
+	"github.com/sgosiaco/pglite-go/pglite"
 )
 
 const tests = `
@@ -52,73 +38,27 @@ SELECT addition(40,2);
 `
 
 func main() {
-	// extract the tar if we don't have tmp dir
-	blob, err := setupEnv()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	ctx := context.Background()
 
-	r := wazero.NewRuntime(ctx)
-	defer r.Close(ctx)
-
-	// setting up dir mounts for r/w
-	fsConfig := wazero.NewFSConfig().WithDirMount("./tmp", "/tmp").WithDirMount("./dev", "/dev")
-
-	config := wazero.NewModuleConfig().
-		WithStdout(os.Stdout).
-		WithStderr(os.Stderr).
-		WithFSConfig(fsConfig)
-	wasi_snapshot_preview1.MustInstantiate(ctx, r)
-
-	pglite, err := r.InstantiateWithConfig(
-		ctx,
-		blob,
-		config.
-			WithArgs("--single", "postgres").
-			WithEnv("ENVIRONMENT", "wasi-embed").
-			WithEnv("REPL", "N").
-			WithEnv("PGUSER", "postgres").
-			WithEnv("PGDATABASE", "postgres"),
-	)
-	if err != nil {
-		// Note: Most compilers do not exit the module after running "_start",
-		// unless there was an error. This allows you to call exported functions.
-		if exitErr, ok := err.(*sys.ExitError); ok && exitErr.ExitCode() != 0 {
-			fmt.Fprintf(os.Stderr, "exit_code: %d\n", exitErr.ExitCode())
-		} else if !ok {
-			log.Panicln(err)
-		}
-	}
-
-	initDBRV, err := pglite.ExportedFunction("pg_initdb").Call(ctx)
+	db, err := pglite.Open(ctx, "./tmp")
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer db.Close(ctx)
 
-	fmt.Printf("initdb returned: %b\n", initDBRV)
-
-	_, err = pglite.ExportedFunction("use_socketfile").Call(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	query := func(input string) error {
-		sqlCstring := append([]byte(input), 0)
-		pglite.Memory().Write(1, sqlCstring)
-
-		_, err = pglite.ExportedFunction("interactive_one").Call(ctx)
-		return err
+	run := func(sql string) {
+		rows, err := db.QueryContext(ctx, sql)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printRows(rows)
 	}
 
 	// run tests
 	for _, line := range strings.Split(tests, "\n\n") {
 		if trimmed := strings.TrimSpace(line); trimmed != "" {
 			fmt.Println("REPL:", line)
-			if err := query(line); err != nil {
-				log.Fatal(err)
-			}
+			run(line)
 		}
 	}
 
@@ -128,82 +68,27 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-
-		if err := query(input); err != nil {
-			log.Fatal(err)
-		}
+		run(input)
 	}
 }
 
-func setupEnv() ([]byte, error) {
-	// check if tar.gz already extracted; if not do so
-	if _, err := os.Stat("./tmp/pglite/base/PG_VERSION"); err != nil {
-		fmt.Println("Extracting env....")
-		gr, err := gzip.NewReader(bytes.NewReader(compressed))
-		if err != nil {
-			return nil, err
-		}
-		defer gr.Close()
-
-		tr := tar.NewReader(gr)
-
-		for {
-			header, err := tr.Next()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				return nil, err
-			}
-
-			dest := filepath.Join("./", header.Name)
-
-			switch header.Typeflag {
-			case tar.TypeDir:
-				if err := os.MkdirAll(dest, os.FileMode(header.Mode)); err != nil {
-					return nil, err
-				}
-			case tar.TypeReg:
-				if err := os.MkdirAll(filepath.Dir(dest), os.FileMode(header.Mode)); err != nil {
-					return nil, err
-				}
-
-				of, err := os.Create(dest)
-				if err != nil {
-					return nil, err
-				}
-				defer of.Close()
-
-				if _, err := io.Copy(of, tr); err != nil {
-					return nil, err
-				}
-			case tar.TypeSymlink:
-				if err := os.Symlink(header.Linkname, dest); err != nil {
-					return nil, err
-				}
-			default:
-				return nil, fmt.Errorf("unknown file type in tar: %c (%s)", header.Typeflag, header.Name)
-			}
-		}
+func printRows(rows *pglite.Rows) {
+	names := make([]string, len(rows.Columns))
+	for i, c := range rows.Columns {
+		names[i] = c.Name
 	}
-
-	// setup random
-	if err := os.MkdirAll("./dev", 0755); err != nil {
-		return nil, err
-	}
-
-	rf, err := os.Create("./dev/urandom")
-	if err != nil {
-		return nil, err
+	if len(names) > 0 {
+		fmt.Println(strings.Join(names, "\t"))
 	}
-	defer rf.Close()
-
-	rng := make([]byte, 128)
-	if _, err := rand.Read(rng); err != nil {
-		return nil, err
+	for _, row := range rows.Values {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			if v == nil {
+				cells[i] = "NULL"
+			} else {
+				cells[i] = string(v)
+			}
+		}
+		fmt.Println(strings.Join(cells, "\t"))
 	}
-	rf.Write(rng)
-
-	// read in wasi blob
-	return os.ReadFile("./tmp/pglite/bin/postgres.wasi")
 }
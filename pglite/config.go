@@ -0,0 +1,197 @@
+package pglite
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sgosiaco/pglite-go/pglite/memfs"
+	"github.com/tetratelabs/wazero"
+	experimentalsysfs "github.com/tetratelabs/wazero/experimental/sysfs"
+)
+
+// Config controls where a DB's data directory lives.
+type Config struct {
+	// FS selects the backend /tmp is mounted from. If nil, OpenConfig
+	// defaults to DirBackend{Path: "./tmp"}; Open, OpenMemory and OpenDir
+	// set it for you.
+	FS Backend
+}
+
+// Backend mounts a DB's /tmp directory for wazero, seeding it with the
+// embedded base image on first use, and cleans it up again on Close.
+type Backend interface {
+	mount(fsConfig wazero.FSConfig) (mount, error)
+}
+
+// mount is everything openConfig needs from a Backend once it's ready.
+type mount struct {
+	fsConfig wazero.FSConfig
+	socket   socketIO
+	snapshot func(tw *tar.Writer) error
+	cleanup  func() error
+}
+
+// DirBackend mounts an existing directory on disk. It's the default when
+// a plain path is passed to Open.
+type DirBackend struct {
+	// Path is the host directory to mount as /tmp. It's created if it
+	// doesn't already exist.
+	Path string
+}
+
+func (b DirBackend) mount(fsConfig wazero.FSConfig) (mount, error) {
+	if err := os.MkdirAll(b.Path, 0o755); err != nil {
+		return mount{}, err
+	}
+	if err := seedDir(b.Path); err != nil {
+		return mount{}, err
+	}
+	return mount{
+		fsConfig: fsConfig.WithDirMount(b.Path, "/tmp"),
+		socket:   diskSocket{path: filepath.Join(b.Path, socketFile)},
+		snapshot: func(tw *tar.Writer) error { return writeDirTar(tw, b.Path) },
+		cleanup:  func() error { return nil },
+	}, nil
+}
+
+// TempBackend allocates a fresh OS temp directory and removes it on
+// Close. Use it for scratch databases that should still exercise a real
+// on-disk filesystem.
+type TempBackend struct{}
+
+func (b TempBackend) mount(fsConfig wazero.FSConfig) (mount, error) {
+	dir, err := os.MkdirTemp("", "pglite-*")
+	if err != nil {
+		return mount{}, err
+	}
+	if err := seedDir(dir); err != nil {
+		os.RemoveAll(dir)
+		return mount{}, err
+	}
+	return mount{
+		fsConfig: fsConfig.WithDirMount(dir, "/tmp"),
+		socket:   diskSocket{path: filepath.Join(dir, socketFile)},
+		snapshot: func(tw *tar.Writer) error { return writeDirTar(tw, dir) },
+		cleanup:  func() error { return os.RemoveAll(dir) },
+	}, nil
+}
+
+// MemoryBackend keeps every file in RAM via pglite/memfs, for fully
+// ephemeral databases used in unit tests. Nothing is ever written to
+// disk, and Close just drops the whole thing.
+type MemoryBackend struct{}
+
+func (b MemoryBackend) mount(fsConfig wazero.FSConfig) (mount, error) {
+	mem := memfs.New()
+	if err := seedMemFS(mem); err != nil {
+		return mount{}, err
+	}
+	return mount{
+		fsConfig: fsConfig.(experimentalsysfs.FSConfig).WithSysFSMount(memSysFS{mem: mem}, "/tmp"),
+		socket:   memSocket{mem: mem, name: socketFile},
+		snapshot: func(tw *tar.Writer) error { return writeMemFSTar(tw, mem) },
+		cleanup:  func() error { return nil },
+	}, nil
+}
+
+// OpenDir is a convenience constructor equivalent to Open(ctx, path).
+func OpenDir(ctx context.Context, path string) (*DB, error) {
+	return openConfig(ctx, Config{FS: DirBackend{Path: path}})
+}
+
+// OpenMemory opens a fully in-memory, disk-free database. It's meant for
+// unit tests and other short-lived, ephemeral uses.
+func OpenMemory(ctx context.Context) (*DB, error) {
+	return openConfig(ctx, Config{FS: MemoryBackend{}})
+}
+
+// seedDir extracts the embedded base image into dir on disk, unless it
+// looks like that's already been done.
+func seedDir(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "pglite/base/PG_VERSION")); err == nil {
+		return nil
+	}
+	return withTarReader(func(tr *tar.Reader) error {
+		return extractTar(tr, dir)
+	})
+}
+
+// seedMemFS extracts the embedded base image straight into an in-memory
+// filesystem, skipping the postgres.wasi binary, which the guest never
+// needs to see under /tmp.
+func seedMemFS(mem *memfs.FS) error {
+	return withTarReader(func(tr *tar.Reader) error {
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if header.Name == "pglite/bin/postgres.wasi" {
+				continue
+			}
+
+			switch header.Typeflag {
+			case tar.TypeDir:
+				if err := mem.Mkdir(header.Name, os.FileMode(header.Mode)); err != nil {
+					return err
+				}
+			case tar.TypeReg:
+				f, err := mem.OpenFile(header.Name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+				if err != nil {
+					return err
+				}
+				if _, err := io.Copy(f.(io.Writer), tr); err != nil {
+					f.Close()
+					return err
+				}
+				f.Close()
+			default:
+				// Symlinks and friends aren't needed for the base image
+				// and memfs doesn't model them; skip.
+			}
+		}
+	})
+}
+
+// withTarReader gunzips the embedded image and hands a fresh tar.Reader
+// to fn.
+func withTarReader(fn func(*tar.Reader) error) error {
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	return fn(tar.NewReader(gr))
+}
+
+// loadWASIBinary extracts the postgres.wasi bytes used to instantiate
+// the wazero module. It's independent of which Backend is in use, since
+// the binary itself is never part of PGDATA.
+func loadWASIBinary() ([]byte, error) {
+	var blob []byte
+	err := withTarReader(func(tr *tar.Reader) error {
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return fmt.Errorf("postgres.wasi not found in embedded image")
+			}
+			if err != nil {
+				return err
+			}
+			if header.Name == "pglite/bin/postgres.wasi" {
+				blob, err = io.ReadAll(tr)
+				return err
+			}
+		}
+	})
+	return blob, err
+}
@@ -0,0 +1,46 @@
+package pglite
+
+import (
+	"crypto/rand"
+	"io/fs"
+	"time"
+)
+
+// devFS mounts a synthesized /dev/urandom backed directly by
+// crypto/rand, replacing the one-shot 128-byte snapshot that used to be
+// written to disk the first time a database was opened.
+type devFS struct{}
+
+func (devFS) Open(name string) (fs.File, error) {
+	if name != "urandom" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &urandomFile{}, nil
+}
+
+func (devFS) Stat(name string) (fs.FileInfo, error) {
+	if name != "urandom" {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return urandomInfo{}, nil
+}
+
+// urandomFile streams crypto/rand on every read; it never runs dry, like
+// the real /dev/urandom.
+type urandomFile struct{}
+
+func (f *urandomFile) Stat() (fs.FileInfo, error) { return urandomInfo{}, nil }
+func (f *urandomFile) Read(p []byte) (int, error) { return rand.Read(p) }
+func (f *urandomFile) Close() error               { return nil }
+
+type urandomInfo struct{}
+
+func (urandomInfo) Name() string       { return "urandom" }
+func (urandomInfo) Size() int64        { return 0 }
+func (urandomInfo) Mode() fs.FileMode  { return 0o444 }
+func (urandomInfo) ModTime() time.Time { return time.Time{} }
+func (urandomInfo) IsDir() bool        { return false }
+func (urandomInfo) Sys() any           { return nil }
+
+var _ fs.FS = devFS{}
+var _ fs.StatFS = devFS{}
@@ -0,0 +1,278 @@
+package pglite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	sql.Register("pglite", &sqlDriver{})
+}
+
+// sqlDriver adapts *DB to database/sql, so callers can do
+// sql.Open("pglite", "file:./data") and use the standard *sql.DB API.
+// PGlite has exactly one backend session per data directory, with no
+// notion of per-connection state (no isolated transactions, no
+// per-connection search_path) — two *sql.Conns open against the same
+// dataDir at once would silently interleave their queries into that one
+// session. database/sql's pool happily hands out concurrent conns for
+// the same DSN, so sqlDriver enforces the one-session-per-dataDir
+// invariant itself: Open refuses (with an explicit error) to open a
+// second conn for a dataDir that already has one open, instead of
+// quietly sharing a *DB across conns the way an earlier version of this
+// driver did.
+type sqlDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*sharedDB
+}
+
+// sharedDB is one dataDir's *DB plus its single open conn's state.
+// stmtCounter lives here rather than on conn so statement names stay
+// unique for as long as this *DB's backend session lives. Closing the
+// conn drops the sharedDB; a later Open of the same dataDir boots a new
+// *DB with a new sharedDB, whose names start again at "stmt1".
+type sharedDB struct {
+	db          *DB
+	stmtCounter int64
+}
+
+// nextStmtName returns a prepared statement name that's unique across
+// every conn sharing this *DB, not just the caller's own conn.
+func (s *sharedDB) nextStmtName() string {
+	return fmt.Sprintf("stmt%d", atomic.AddInt64(&s.stmtCounter, 1))
+}
+
+var _ driver.Driver = (*sqlDriver)(nil)
+
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	dataDir := strings.TrimPrefix(name, "file:")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dbs == nil {
+		d.dbs = make(map[string]*sharedDB)
+	}
+	if _, ok := d.dbs[dataDir]; ok {
+		return nil, fmt.Errorf("pglite: %q already has an open connection; PGlite has one backend session per data directory and cannot serve two *sql.Conns concurrently", dataDir)
+	}
+
+	db, err := Open(context.Background(), dataDir)
+	if err != nil {
+		return nil, err
+	}
+	shared := &sharedDB{db: db}
+	d.dbs[dataDir] = shared
+
+	return &conn{driver: d, dataDir: dataDir, db: shared.db, shared: shared}, nil
+}
+
+type conn struct {
+	driver  *sqlDriver
+	dataDir string
+	db      *DB
+	shared  *sharedDB
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close closes the underlying module and frees this dataDir up for a
+// future Open to reconnect to it.
+func (c *conn) Close() error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+
+	if _, ok := c.driver.dbs[c.dataDir]; !ok {
+		return nil
+	}
+	delete(c.driver.dbs, c.dataDir)
+	return c.db.Close(context.Background())
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errors.New("pglite: transactions not yet supported")
+}
+
+// stmt is a database/sql statement. Without parameters it runs query
+// through the simple-query path; the first call that passes arguments
+// switches it over to a named prepared statement bound through the
+// extended query protocol (see protocol.go).
+type stmt struct {
+	conn     *conn
+	query    string
+	prepared *Stmt
+}
+
+// Close releases the backend's named prepared statement, if binding
+// parameters ever caused one to be created. database/sql calls this
+// after every one-off Exec/Query as well as when an explicit *sql.Stmt
+// is closed, so this is what keeps named statements from accumulating
+// on the PGlite backend forever.
+func (s *stmt) Close() error {
+	if s.prepared == nil {
+		return nil
+	}
+	return s.prepared.Close(context.Background())
+}
+
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) == 0 {
+		result, err := s.conn.db.ExecContext(ctx, s.query)
+		if err != nil {
+			return nil, err
+		}
+		return execResult{result}, nil
+	}
+
+	prepared, params, err := s.bind(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	result, err := prepared.ExecContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	return execResult{result}, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) == 0 {
+		rows, err := s.conn.db.QueryContext(ctx, s.query)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlRows{rows: rows}, nil
+	}
+
+	prepared, params, err := s.bind(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := prepared.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlRows{rows: rows}, nil
+}
+
+// bind lazily parses s.query into a named prepared statement the first
+// time it's called with parameters, then converts args to Params.
+func (s *stmt) bind(ctx context.Context, args []driver.NamedValue) (*Stmt, []Param, error) {
+	if s.prepared == nil {
+		name := s.conn.shared.nextStmtName()
+		prepared, err := s.conn.db.PrepareContext(ctx, name, s.query)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.prepared = prepared
+	}
+
+	params := make([]Param, len(args))
+	for i, a := range args {
+		p, err := paramFromDriverValue(a.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		params[i] = p
+	}
+	return s.prepared, params, nil
+}
+
+// paramFromDriverValue converts a database/sql-normalized driver.Value
+// (always int64, float64, bool, []byte, string, time.Time, or nil) into
+// a typed Param for the extended query protocol.
+func paramFromDriverValue(v driver.Value) (Param, error) {
+	switch x := v.(type) {
+	case nil:
+		return NullParam(OIDText), nil
+	case int64:
+		return Int8Param(x), nil
+	case float64:
+		return Float8Param(x), nil
+	case bool:
+		return BoolParam(x), nil
+	case []byte:
+		return ByteaParam(x), nil
+	case string:
+		return TextParam(x), nil
+	case time.Time:
+		return TimestampParam(x), nil
+	default:
+		return Param{}, fmt.Errorf("pglite: unsupported parameter type %T", v)
+	}
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type execResult struct {
+	result *Result
+}
+
+func (r execResult) LastInsertId() (int64, error) {
+	return 0, errors.New("pglite: LastInsertId is not supported, use RETURNING")
+}
+
+func (r execResult) RowsAffected() (int64, error) {
+	return r.result.RowsAffected, nil
+}
+
+// sqlRows adapts *Rows to driver.Rows.
+type sqlRows struct {
+	rows *Rows
+	next int
+}
+
+func (r *sqlRows) Columns() []string {
+	names := make([]string, len(r.rows.Columns))
+	for i, c := range r.rows.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func (r *sqlRows) Close() error { return nil }
+
+func (r *sqlRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows.Values) {
+		return io.EOF
+	}
+	row := r.rows.Values[r.next]
+	r.next++
+	for i, raw := range row {
+		v, err := Decode(r.rows.Columns[i], raw)
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+var _ driver.StmtExecContext = (*stmt)(nil)
+var _ driver.StmtQueryContext = (*stmt)(nil)
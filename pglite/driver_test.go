@@ -0,0 +1,82 @@
+package pglite
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestSharedDBStmtNamesUniqueAcrossConns guards against a regression
+// where the prepared-statement counter lived on *conn instead of
+// *sharedDB: two conns using the same *sharedDB must not both name their
+// first parameterized statement "stmt1".
+func TestSharedDBStmtNamesUniqueAcrossConns(t *testing.T) {
+	shared := &sharedDB{}
+	connA := &conn{shared: shared}
+	connB := &conn{shared: shared}
+
+	nameA := connA.shared.nextStmtName()
+	nameB := connB.shared.nextStmtName()
+	if nameA == nameB {
+		t.Fatalf("two conns sharing a *sharedDB produced the same statement name %q", nameA)
+	}
+}
+
+// TestSharedDBStmtNamesConcurrentlyUnique exercises nextStmtName from
+// many goroutines at once, since it's incremented atomically rather
+// than guarded by sqlDriver.mu.
+func TestSharedDBStmtNamesConcurrentlyUnique(t *testing.T) {
+	shared := &sharedDB{}
+	const n = 100
+
+	names := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			names[i] = shared.nextStmtName()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, n)
+	for _, name := range names {
+		if seen[name] {
+			t.Fatalf("duplicate statement name %q produced under concurrent use", name)
+		}
+		seen[name] = true
+	}
+}
+
+// TestSQLDriverRefusesSecondConnForSameDataDir guards against a
+// regression where every conn opened for a dataDir shared one
+// underlying *DB: PGlite has exactly one backend session per data
+// directory, so a second concurrent *sql.Conn sharing it could
+// interleave queries into whatever transaction the first conn had open.
+// Open must refuse a second conn for a dataDir that already has one.
+func TestSQLDriverRefusesSecondConnForSameDataDir(t *testing.T) {
+	dir := t.TempDir()
+	d := &sqlDriver{}
+
+	first, err := d.Open("file:" + dir)
+	if err != nil {
+		t.Fatalf("first Open: %v", err)
+	}
+	defer first.Close()
+
+	if _, err := d.Open("file:" + dir); err == nil {
+		t.Fatal("expected a second Open for the same dataDir to be refused")
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	second, err := d.Open("file:" + dir)
+	if err != nil {
+		t.Fatalf("Open after Close: %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
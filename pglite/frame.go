@@ -0,0 +1,55 @@
+package pglite
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// frame is a single PostgreSQL wire-protocol message: a one-byte type tag
+// followed by its payload (the length prefix is added/stripped on the
+// wire, not stored here).
+type frame struct {
+	Type    byte
+	Payload []byte
+}
+
+// encodeFrame serializes a frame the way PGlite expects to find it on the
+// socket file: type byte, big-endian int32 length (including itself),
+// payload.
+func encodeFrame(f frame) []byte {
+	buf := make([]byte, 0, 5+len(f.Payload))
+	buf = append(buf, f.Type)
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(4+len(f.Payload)))
+	buf = append(buf, length[:]...)
+	buf = append(buf, f.Payload...)
+	return buf
+}
+
+// decodeFrames splits a buffer of back-to-back wire messages into frames.
+// It returns the frames found plus any trailing bytes that don't yet form
+// a complete message.
+func decodeFrames(buf []byte) (frames []frame, rest []byte, err error) {
+	for len(buf) > 0 {
+		if len(buf) < 5 {
+			break
+		}
+		length := binary.BigEndian.Uint32(buf[1:5])
+		if length < 4 {
+			return nil, nil, fmt.Errorf("pglite: invalid frame length %d", length)
+		}
+		total := 1 + int(length)
+		if len(buf) < total {
+			break
+		}
+		frames = append(frames, frame{Type: buf[0], Payload: buf[5:total]})
+		buf = buf[total:]
+	}
+	return frames, buf, nil
+}
+
+// cstring NUL-terminates s, the form PGlite expects for query text and
+// other string fields embedded in a frame payload.
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
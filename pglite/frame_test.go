@@ -0,0 +1,75 @@
+package pglite
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeFrame(t *testing.T) {
+	got := encodeFrame(frame{Type: 'Q', Payload: []byte("SELECT 1")})
+	want := append([]byte{'Q', 0, 0, 0, 12}, []byte("SELECT 1")...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestEncodeFrameEmptyPayload(t *testing.T) {
+	got := encodeFrame(frame{Type: 'S'})
+	want := []byte{'S', 0, 0, 0, 4}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeFramesRoundTrip(t *testing.T) {
+	buf := append(encodeFrame(frame{Type: 'Q', Payload: []byte("a")}), encodeFrame(frame{Type: 'S'})...)
+
+	frames, rest, err := decodeFrames(buf)
+	if err != nil {
+		t.Fatalf("decodeFrames: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("rest = %v, want empty", rest)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if frames[0].Type != 'Q' || string(frames[0].Payload) != "a" {
+		t.Errorf("frames[0] = %+v", frames[0])
+	}
+	if frames[1].Type != 'S' || len(frames[1].Payload) != 0 {
+		t.Errorf("frames[1] = %+v", frames[1])
+	}
+}
+
+func TestDecodeFramesReturnsTrailingPartialMessage(t *testing.T) {
+	full := encodeFrame(frame{Type: 'Q', Payload: []byte("a")})
+	partial := []byte{'Q', 0, 0, 0}
+	buf := append(append([]byte{}, full...), partial...)
+
+	frames, rest, err := decodeFrames(buf)
+	if err != nil {
+		t.Fatalf("decodeFrames: %v", err)
+	}
+	if len(frames) != 1 {
+		t.Fatalf("got %d frames, want 1", len(frames))
+	}
+	if !bytes.Equal(rest, partial) {
+		t.Errorf("rest = %v, want %v", rest, partial)
+	}
+}
+
+func TestDecodeFramesRejectsInvalidLength(t *testing.T) {
+	buf := []byte{'Q', 0, 0, 0, 0}
+	if _, _, err := decodeFrames(buf); err == nil {
+		t.Fatal("expected an error for a frame length < 4")
+	}
+}
+
+func TestCstring(t *testing.T) {
+	got := cstring("hi")
+	want := []byte{'h', 'i', 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,268 @@
+// Package memfs is a minimal in-memory, read-write filesystem. It backs
+// fully ephemeral PGlite databases (pglite.OpenMemory) so that nothing
+// ever touches disk.
+package memfs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// FS is an in-memory directory tree, safe for concurrent use. The zero
+// value is not usable; construct one with New.
+type FS struct {
+	mu    sync.Mutex
+	nodes map[string]*node
+}
+
+type node struct {
+	dir  bool
+	mode fs.FileMode
+	buf  []byte
+}
+
+// New returns an empty in-memory filesystem containing just its root.
+func New() *FS {
+	return &FS{nodes: map[string]*node{".": {dir: true, mode: fs.ModeDir | 0o755}}}
+}
+
+func clean(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("invalid path %q", name)
+	}
+	return name, nil
+}
+
+// Open implements fs.FS, opening name read-only.
+func (f *FS) Open(name string) (fs.File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens or creates name depending on flag, in the style of
+// os.OpenFile. Callers pass the usual os.O_* flags.
+func (f *FS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	name, err := clean(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.nodes[name]
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		if err := f.mkdirAllLocked(path.Dir(name)); err != nil {
+			return nil, err
+		}
+		n = &node{mode: perm}
+		f.nodes[name] = n
+	}
+	if n.dir {
+		return &dirHandle{fs: f, name: name}, nil
+	}
+	if flag&os.O_TRUNC != 0 {
+		n.buf = nil
+	}
+
+	return &fileHandle{fs: f, node: n, name: name, writable: flag&os.O_WRONLY != 0, append: flag&os.O_APPEND != 0}, nil
+}
+
+// Mkdir creates name and any missing parents.
+func (f *FS) Mkdir(name string, perm fs.FileMode) error {
+	name, err := clean(name)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mkdirAllLocked(name)
+}
+
+func (f *FS) mkdirAllLocked(name string) error {
+	if name == "." {
+		return nil
+	}
+	if n, ok := f.nodes[name]; ok {
+		if !n.dir {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+		}
+		return nil
+	}
+	if err := f.mkdirAllLocked(path.Dir(name)); err != nil {
+		return err
+	}
+	f.nodes[name] = &node{dir: true, mode: fs.ModeDir | 0o755}
+	return nil
+}
+
+// Remove deletes the regular file name. It returns an error if name
+// doesn't exist or is a directory; memfs never needs to remove those.
+func (f *FS) Remove(name string) error {
+	name, err := clean(name)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.nodes[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.dir {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	delete(f.nodes, name)
+	return nil
+}
+
+// Walk calls fn once per node (files and directories alike, "." excluded)
+// in an unspecified order. It's used by pglite.DB.Snapshot to serialize
+// an in-memory database to a tar archive.
+func (f *FS) Walk(fn func(name string, mode fs.FileMode, data []byte) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, n := range f.nodes {
+		if name == "." {
+			continue
+		}
+		if err := fn(name, n.mode, n.buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stat implements fs.StatFS.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	name, err := clean(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fileInfo{name: path.Base(name), node: n}, nil
+}
+
+type fileInfo struct {
+	name string
+	node *node
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return int64(len(i.node.buf)) }
+func (i fileInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i fileInfo) ModTime() time.Time { return time.Time{} }
+func (i fileInfo) IsDir() bool        { return i.node.dir }
+func (i fileInfo) Sys() any           { return nil }
+
+// fileHandle is an open regular file; reads and writes share a single
+// cursor, like a real *os.File.
+type fileHandle struct {
+	fs       *FS
+	node     *node
+	name     string
+	pos      int
+	writable bool
+	append   bool
+}
+
+func (h *fileHandle) Stat() (fs.FileInfo, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	return fileInfo{name: path.Base(h.name), node: h.node}, nil
+}
+
+func (h *fileHandle) Read(p []byte) (int, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if h.pos >= len(h.node.buf) {
+		return 0, io.EOF
+	}
+	n := copy(p, h.node.buf[h.pos:])
+	h.pos += n
+	return n, nil
+}
+
+func (h *fileHandle) Write(p []byte) (int, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if h.append {
+		h.pos = len(h.node.buf)
+	}
+	if h.pos+len(p) > len(h.node.buf) {
+		grown := make([]byte, h.pos+len(p))
+		copy(grown, h.node.buf)
+		h.node.buf = grown
+	}
+	n := copy(h.node.buf[h.pos:], p)
+	h.pos += n
+	return n, nil
+}
+
+func (h *fileHandle) Seek(offset int64, whence int) (int64, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+		h.pos = int(offset)
+	case io.SeekCurrent:
+		h.pos += int(offset)
+	case io.SeekEnd:
+		h.pos = len(h.node.buf) + int(offset)
+	}
+	return int64(h.pos), nil
+}
+
+func (h *fileHandle) Close() error { return nil }
+
+// Truncate resizes the file to size, zero-filling any new space when
+// growing it. It's used by the experimental/sys.File adapter to back
+// WASI's ftruncate.
+func (h *fileHandle) Truncate(size int64) error {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: h.name, Err: fs.ErrInvalid}
+	}
+	if int(size) <= len(h.node.buf) {
+		h.node.buf = h.node.buf[:size]
+		return nil
+	}
+	grown := make([]byte, size)
+	copy(grown, h.node.buf)
+	h.node.buf = grown
+	return nil
+}
+
+// dirHandle is an open directory; it only needs to support Stat/Close to
+// satisfy fs.File, since PGlite never lists /tmp's contents through the
+// guest FS API.
+type dirHandle struct {
+	fs   *FS
+	name string
+}
+
+func (h *dirHandle) Stat() (fs.FileInfo, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	return fileInfo{name: path.Base(h.name), node: h.fs.nodes[h.name]}, nil
+}
+func (h *dirHandle) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: h.name, Err: fs.ErrInvalid}
+}
+func (h *dirHandle) Close() error { return nil }
+
+var _ fs.FS = (*FS)(nil)
+var _ fs.StatFS = (*FS)(nil)
@@ -0,0 +1,147 @@
+package memfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestMkdirAndOpenFileCreate(t *testing.T) {
+	f := New()
+
+	if err := f.Mkdir("base", 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if info, err := f.Stat("base"); err != nil || !info.IsDir() {
+		t.Fatalf("Stat(base): info=%v err=%v", info, err)
+	}
+
+	// OpenFile with O_CREATE should make parents that don't exist yet.
+	wf, err := f.OpenFile("base/deep/new.txt", os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile create: %v", err)
+	}
+	if _, err := wf.(io.Writer).Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := wf.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rf, err := f.Open("base/deep/new.txt")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rf.Close()
+	got, err := io.ReadAll(rf)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestOpenFileWithoutCreateFailsForMissingFile(t *testing.T) {
+	f := New()
+	if _, err := f.OpenFile("missing.txt", os.O_RDONLY, 0); !fs.ValidPath("missing.txt") || err == nil {
+		t.Fatalf("expected an error opening a nonexistent file without O_CREATE, got %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	f := New()
+	wf, err := f.OpenFile("file.txt", os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	wf.Close()
+
+	if err := f.Remove("file.txt"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := f.Stat("file.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Stat after Remove: err = %v, want ErrNotExist", err)
+	}
+
+	if err := f.Remove("file.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Remove of missing file: err = %v, want ErrNotExist", err)
+	}
+
+	if err := f.Mkdir("dir", 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := f.Remove("dir"); err == nil {
+		t.Fatal("expected Remove to reject a directory")
+	}
+}
+
+func TestWalkVisitsAllNodesExceptRoot(t *testing.T) {
+	f := New()
+	if err := f.Mkdir("a/b", 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	wf, err := f.OpenFile("a/b/c.txt", os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := wf.(io.Writer).Write([]byte("data")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	wf.Close()
+
+	seen := map[string]bool{}
+	err = f.Walk(func(name string, mode fs.FileMode, data []byte) error {
+		seen[name] = true
+		if name == "." {
+			t.Fatal("Walk should not visit the root")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	for _, want := range []string{"a", "a/b", "a/b/c.txt"} {
+		if !seen[want] {
+			t.Errorf("Walk didn't visit %q", want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	f := New()
+	wf, err := f.OpenFile("file.txt", os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := wf.(io.Writer).Write([]byte("hello world")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	truncater, ok := wf.(interface{ Truncate(int64) error })
+	if !ok {
+		t.Fatal("fileHandle doesn't implement Truncate")
+	}
+	if err := truncater.Truncate(5); err != nil {
+		t.Fatalf("Truncate shrink: %v", err)
+	}
+	if err := truncater.Truncate(8); err != nil {
+		t.Fatalf("Truncate grow: %v", err)
+	}
+	wf.Close()
+
+	rf, err := f.Open("file.txt")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rf.Close()
+	got, err := io.ReadAll(rf)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	want := []byte{'h', 'e', 'l', 'l', 'o', 0, 0, 0}
+	if string(got) != string(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
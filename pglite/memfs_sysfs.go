@@ -0,0 +1,210 @@
+package pglite
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/sgosiaco/pglite-go/pglite/memfs"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// memSysFS adapts *memfs.FS to wazero's experimental/sys.FS, the writable
+// filesystem ABI WithSysFSMount expects. The generic fs.FS adapter used by
+// WithFSMount never creates new guest-side state (Mkdir always returns
+// ENOSYS, and OpenFile ignores O_CREAT), so MemoryBackend needs this
+// instead for the WASM guest to create new relation files, WAL segments,
+// and temp files under /tmp.
+type memSysFS struct {
+	experimentalsys.UnimplementedFS
+	mem *memfs.FS
+}
+
+var _ experimentalsys.FS = memSysFS{}
+
+func (m memSysFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	f, err := m.mem.OpenFile(path, oflagToOS(flag), perm)
+	if err != nil {
+		return nil, errnoFor(err)
+	}
+	return &memSysFile{f: f}, 0
+}
+
+func (m memSysFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	info, err := m.mem.Stat(path)
+	if err != nil {
+		return sys.Stat_t{}, errnoFor(err)
+	}
+	return sys.NewStat_t(info), 0
+}
+
+// Lstat is the same as Stat: memfs has no symlinks.
+func (m memSysFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return m.Stat(path)
+}
+
+func (m memSysFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	if err := m.mem.Mkdir(path, perm); err != nil {
+		return errnoFor(err)
+	}
+	return 0
+}
+
+func (m memSysFS) Unlink(path string) experimentalsys.Errno {
+	if err := m.mem.Remove(path); err != nil {
+		return errnoFor(err)
+	}
+	return 0
+}
+
+// oflagToOS translates wazero's experimental/sys.Oflag bits (whose access
+// mode occupies the low 2 bits as an enum, unlike os.O_RDONLY/O_WRONLY/
+// O_RDWR's independent bit positions) into the os.O_* flags
+// memfs.FS.OpenFile expects.
+func oflagToOS(flag experimentalsys.Oflag) int {
+	var out int
+	switch flag & 3 {
+	case experimentalsys.O_WRONLY:
+		out |= os.O_WRONLY
+	case experimentalsys.O_RDWR:
+		out |= os.O_RDWR
+	}
+	if flag&experimentalsys.O_CREAT != 0 {
+		out |= os.O_CREATE
+	}
+	if flag&experimentalsys.O_TRUNC != 0 {
+		out |= os.O_TRUNC
+	}
+	if flag&experimentalsys.O_APPEND != 0 {
+		out |= os.O_APPEND
+	}
+	if flag&experimentalsys.O_EXCL != 0 {
+		out |= os.O_EXCL
+	}
+	return out
+}
+
+func errnoFor(err error) experimentalsys.Errno {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return experimentalsys.ENOENT
+	case errors.Is(err, fs.ErrExist):
+		return experimentalsys.EEXIST
+	case errors.Is(err, fs.ErrInvalid):
+		return experimentalsys.EINVAL
+	default:
+		return experimentalsys.EIO
+	}
+}
+
+// memSysFile adapts the fs.File handles memfs.FS.OpenFile returns to
+// experimental/sys.File.
+type memSysFile struct {
+	experimentalsys.UnimplementedFile
+	f fs.File
+}
+
+func (h *memSysFile) IsDir() (bool, experimentalsys.Errno) {
+	info, err := h.f.Stat()
+	if err != nil {
+		return false, errnoFor(err)
+	}
+	return info.IsDir(), 0
+}
+
+func (h *memSysFile) Stat() (sys.Stat_t, experimentalsys.Errno) {
+	info, err := h.f.Stat()
+	if err != nil {
+		return sys.Stat_t{}, errnoFor(err)
+	}
+	return sys.NewStat_t(info), 0
+}
+
+func (h *memSysFile) Read(buf []byte) (int, experimentalsys.Errno) {
+	n, err := h.f.Read(buf)
+	if err != nil && err != io.EOF {
+		return n, errnoFor(err)
+	}
+	return n, 0
+}
+
+func (h *memSysFile) Pread(buf []byte, off int64) (int, experimentalsys.Errno) {
+	seeker, ok := h.f.(io.Seeker)
+	if !ok {
+		return 0, experimentalsys.ENOSYS
+	}
+	cur, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, errnoFor(err)
+	}
+	defer seeker.Seek(cur, io.SeekStart)
+
+	if _, err := seeker.Seek(off, io.SeekStart); err != nil {
+		return 0, errnoFor(err)
+	}
+	return h.Read(buf)
+}
+
+func (h *memSysFile) Write(buf []byte) (int, experimentalsys.Errno) {
+	w, ok := h.f.(io.Writer)
+	if !ok {
+		return 0, experimentalsys.ENOSYS
+	}
+	n, err := w.Write(buf)
+	if err != nil {
+		return n, errnoFor(err)
+	}
+	return n, 0
+}
+
+func (h *memSysFile) Pwrite(buf []byte, off int64) (int, experimentalsys.Errno) {
+	seeker, ok := h.f.(io.Seeker)
+	if !ok {
+		return 0, experimentalsys.ENOSYS
+	}
+	cur, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, errnoFor(err)
+	}
+	defer seeker.Seek(cur, io.SeekStart)
+
+	if _, err := seeker.Seek(off, io.SeekStart); err != nil {
+		return 0, errnoFor(err)
+	}
+	return h.Write(buf)
+}
+
+func (h *memSysFile) Seek(offset int64, whence int) (int64, experimentalsys.Errno) {
+	seeker, ok := h.f.(io.Seeker)
+	if !ok {
+		return 0, experimentalsys.ENOSYS
+	}
+	n, err := seeker.Seek(offset, whence)
+	if err != nil {
+		return 0, errnoFor(err)
+	}
+	return n, 0
+}
+
+func (h *memSysFile) Truncate(size int64) experimentalsys.Errno {
+	t, ok := h.f.(interface{ Truncate(int64) error })
+	if !ok {
+		return experimentalsys.ENOSYS
+	}
+	if err := t.Truncate(size); err != nil {
+		return errnoFor(err)
+	}
+	return 0
+}
+
+func (h *memSysFile) Sync() experimentalsys.Errno     { return 0 }
+func (h *memSysFile) Datasync() experimentalsys.Errno { return 0 }
+
+func (h *memSysFile) Close() experimentalsys.Errno {
+	if err := h.f.Close(); err != nil {
+		return errnoFor(err)
+	}
+	return 0
+}
@@ -0,0 +1,268 @@
+// Package migrate adds up/down schema versioning on top of a *pglite.DB,
+// so an embedded PGlite database can ship its own migration history
+// instead of being a bare SQL REPL.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sgosiaco/pglite-go/pglite"
+)
+
+// Migration is one numbered schema step.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// filenamePattern matches the NNNN_name.up.sql / NNNN_name.down.sql
+// convention.
+var filenamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load discovers migrations from fsys (a directory or an embed.FS),
+// pairing up/down files that share a version and name.
+func Load(fsys fs.FS) ([]Migration, error) {
+	byVersion := map[uint]*Migration{}
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		m := filenamePattern.FindStringSubmatch(d.Name())
+		if m == nil {
+			return nil
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrate: bad version in %q: %w", d.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		mig := byVersion[uint(version)]
+		if mig == nil {
+			mig = &Migration{Version: uint(version), Name: m[2]}
+			byVersion[uint(version)] = mig
+		}
+		switch m[3] {
+		case "up":
+			mig.Up = string(data)
+		case "down":
+			mig.Down = string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// DB wraps a *pglite.DB with schema migration support, tracking applied
+// versions in a schema_migrations table inside the embedded database
+// itself.
+type DB struct {
+	*pglite.DB
+	migrations []Migration
+}
+
+// New wraps db with the given migrations, which must already be sorted
+// by version (Load returns them in that order).
+func New(db *pglite.DB, migrations []Migration) *DB {
+	return &DB{DB: db, migrations: migrations}
+}
+
+const createTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, dirty BOOLEAN NOT NULL DEFAULT false)`
+
+// Status is the current migration state of the database.
+type Status struct {
+	Version uint
+	Dirty   bool
+}
+
+// Status reports the latest applied version, and whether it's dirty
+// (the previous Up/Down/To failed partway through).
+func (db *DB) Status(ctx context.Context) (Status, error) {
+	if _, err := db.ExecContext(ctx, createTableSQL); err != nil {
+		return Status{}, fmt.Errorf("migrate: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1")
+	if err != nil {
+		return Status{}, fmt.Errorf("migrate: %w", err)
+	}
+	if len(rows.Values) == 0 {
+		return Status{}, nil
+	}
+
+	version, err := strconv.ParseUint(string(rows.Values[0][0]), 10, 64)
+	if err != nil {
+		return Status{}, fmt.Errorf("migrate: %w", err)
+	}
+	return Status{Version: uint(version), Dirty: string(rows.Values[0][1]) == "t"}, nil
+}
+
+// Up applies up to n pending migrations, in version order. n <= 0 means
+// every pending migration.
+func (db *DB) Up(ctx context.Context, n int) error {
+	status, err := db.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("migrate: database is dirty at version %d, fix manually", status.Version)
+	}
+
+	var pending []Migration
+	for _, m := range db.migrations {
+		if m.Version > status.Version {
+			pending = append(pending, m)
+		}
+	}
+	if n > 0 && n < len(pending) {
+		pending = pending[:n]
+	}
+
+	for _, m := range pending {
+		if err := db.apply(ctx, m, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down rolls back up to n applied migrations, newest first. n <= 0 means
+// every applied migration.
+func (db *DB) Down(ctx context.Context, n int) error {
+	status, err := db.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("migrate: database is dirty at version %d, fix manually", status.Version)
+	}
+
+	var pending []Migration
+	for i := len(db.migrations) - 1; i >= 0; i-- {
+		if db.migrations[i].Version <= status.Version {
+			pending = append(pending, db.migrations[i])
+		}
+	}
+	if n > 0 && n < len(pending) {
+		pending = pending[:n]
+	}
+
+	for _, m := range pending {
+		if err := db.apply(ctx, m, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// To migrates up or down until version is the latest applied version.
+func (db *DB) To(ctx context.Context, version uint) error {
+	status, err := db.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("migrate: database is dirty at version %d, fix manually", status.Version)
+	}
+
+	if version > status.Version {
+		for _, m := range db.migrations {
+			if m.Version > status.Version && m.Version <= version {
+				if err := db.apply(ctx, m, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for i := len(db.migrations) - 1; i >= 0; i-- {
+		m := db.migrations[i]
+		if m.Version <= status.Version && m.Version > version {
+			if err := db.apply(ctx, m, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (db *DB) apply(ctx context.Context, m Migration, up bool) error {
+	sql := m.Up
+	if !up {
+		sql = m.Down
+	}
+	if strings.TrimSpace(sql) == "" {
+		return fmt.Errorf("migrate: version %d has no %s migration", m.Version, direction(up))
+	}
+
+	if _, err := db.ExecContext(ctx, "BEGIN"); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, sql); err != nil {
+		// The session's transaction is aborted at this point, so ROLLBACK
+		// must run before any other statement, including markDirty's.
+		db.ExecContext(ctx, "ROLLBACK")
+		if dirtyErr := db.markDirty(ctx, m.Version); dirtyErr != nil {
+			return fmt.Errorf("migrate: %d_%s.%s.sql: %w (also failed to mark dirty: %v)", m.Version, m.Name, direction(up), err, dirtyErr)
+		}
+		return fmt.Errorf("migrate: %d_%s.%s.sql: %w", m.Version, m.Name, direction(up), err)
+	}
+
+	bookkeeping := fmt.Sprintf("INSERT INTO schema_migrations (version, dirty) VALUES (%d, false)", m.Version)
+	if !up {
+		bookkeeping = fmt.Sprintf("DELETE FROM schema_migrations WHERE version = %d", m.Version)
+	}
+	if _, err := db.ExecContext(ctx, bookkeeping); err != nil {
+		db.ExecContext(ctx, "ROLLBACK")
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "COMMIT"); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	return nil
+}
+
+// markDirty records that version failed partway through, so the next
+// Up/Down/To refuses to run until it's fixed by hand. Callers must run
+// it outside of an aborted transaction (after ROLLBACK, not before).
+func (db *DB) markDirty(ctx context.Context, version uint) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO schema_migrations (version, dirty) VALUES (%d, true) ON CONFLICT (version) DO UPDATE SET dirty = true",
+		version,
+	))
+	return err
+}
+
+func direction(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
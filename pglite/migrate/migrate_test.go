@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPairsUpAndDownByVersionAndSortsThem(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_users.up.sql":     {Data: []byte("CREATE TABLE users ();")},
+		"0002_add_users.down.sql":   {Data: []byte("DROP TABLE users;")},
+		"0001_init.up.sql":          {Data: []byte("CREATE TABLE t ();")},
+		"0001_init.down.sql":        {Data: []byte("DROP TABLE t;")},
+		"not_a_migration.sql":       {Data: []byte("ignored")},
+		"nested/0003_more.up.sql":   {Data: []byte("CREATE TABLE more ();")},
+		"nested/0003_more.down.sql": {Data: []byte("DROP TABLE more;")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("got %d migrations, want 3: %+v", len(migrations), migrations)
+	}
+
+	for i, want := range []struct {
+		version uint
+		name    string
+		up      string
+		down    string
+	}{
+		{1, "init", "CREATE TABLE t ();", "DROP TABLE t;"},
+		{2, "add_users", "CREATE TABLE users ();", "DROP TABLE users;"},
+		{3, "more", "CREATE TABLE more ();", "DROP TABLE more;"},
+	} {
+		m := migrations[i]
+		if m.Version != want.version || m.Name != want.name || m.Up != want.up || m.Down != want.down {
+			t.Errorf("migrations[%d] = %+v, want %+v", i, m, want)
+		}
+	}
+}
+
+func TestLoadAllowsOneSidedMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_up_only.up.sql": {Data: []byte("CREATE TABLE t ();")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 1 {
+		t.Fatalf("got %d migrations, want 1", len(migrations))
+	}
+	if migrations[0].Down != "" {
+		t.Errorf("Down = %q, want empty", migrations[0].Down)
+	}
+}
+
+func TestLoadRejectsUnparsableVersion(t *testing.T) {
+	// filenamePattern requires digits, so this can only fail via
+	// ParseUint overflowing uint64.
+	fsys := fstest.MapFS{
+		"99999999999999999999999_overflow.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	if _, err := Load(fsys); err == nil {
+		t.Fatal("expected an error for a version that overflows uint64")
+	}
+}
+
+func TestLoadIgnoresNonMatchingFilenames(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":              {Data: []byte("docs")},
+		"0001_init.up.sql":       {Data: []byte("CREATE TABLE t ();")},
+		"0001_init.sideways.sql": {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 1 {
+		t.Fatalf("got %d migrations, want 1: %+v", len(migrations), migrations)
+	}
+}
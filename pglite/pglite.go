@@ -0,0 +1,288 @@
+// Package pglite embeds PGlite (Postgres compiled to WASM) and drives it
+// through wazero, exposing it as a reusable *DB instead of the raw REPL
+// loop this project started as.
+package pglite
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+//go:embed pglite-wasi.tar.gz
+var compressed []byte
+
+// socketFile is where PGlite reads and writes wire-protocol frames once
+// use_socketfile has switched it out of plain REPL mode, relative to
+// whatever is mounted at /tmp.
+const socketFile = "pglite/base/.s.PGSQL.5432"
+
+// DB is a single PGlite instance backed by one wazero module, instantiated
+// from the package's shared compiled module (see pool.go). PGlite is
+// single-threaded, so every call into it is serialized through mu.
+type DB struct {
+	mu         sync.Mutex
+	mod        api.Module
+	socket     socketIO
+	snapshotFn func(tw *tar.Writer) error
+	cleanup    func() error
+}
+
+// Open extracts (if needed) and boots a PGlite instance rooted at
+// dataDir, a plain directory on disk. For temp or in-memory databases,
+// use OpenConfig with TempBackend or MemoryBackend.
+func Open(ctx context.Context, dataDir string) (*DB, error) {
+	if dataDir == "" {
+		dataDir = "./tmp"
+	}
+	return OpenConfig(ctx, Config{FS: DirBackend{Path: dataDir}})
+}
+
+// OpenConfig boots a PGlite instance using the backend selected by cfg.FS.
+func OpenConfig(ctx context.Context, cfg Config) (*DB, error) {
+	return openConfig(ctx, cfg)
+}
+
+func openConfig(ctx context.Context, cfg Config) (*DB, error) {
+	backend := cfg.FS
+	if backend == nil {
+		backend = DirBackend{Path: "./tmp"}
+	}
+
+	rt, compiled, err := sharedCompiledModule(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pglite: %w", err)
+	}
+
+	m, err := backend.mount(wazero.NewFSConfig())
+	if err != nil {
+		return nil, fmt.Errorf("pglite: %w", err)
+	}
+	fsConfig := m.fsConfig.WithFSMount(devFS{}, "/dev")
+
+	modConfig := wazero.NewModuleConfig().
+		WithStdout(os.Stdout).
+		WithStderr(os.Stderr).
+		WithFSConfig(fsConfig).
+		WithArgs("--single", "postgres").
+		WithEnv("ENVIRONMENT", "wasi-embed").
+		WithEnv("REPL", "N").
+		WithEnv("PGUSER", "postgres").
+		WithEnv("PGDATABASE", "postgres")
+
+	mod, err := rt.InstantiateModule(ctx, compiled, modConfig)
+	if err != nil {
+		// Note: Most compilers do not exit the module after running
+		// "_start", unless there was an error. This allows callers to
+		// still invoke exported functions below.
+		if exitErr, ok := err.(*sys.ExitError); !ok || exitErr.ExitCode() != 0 {
+			m.cleanup()
+			return nil, fmt.Errorf("pglite: instantiate module: %w", err)
+		}
+	}
+
+	if _, err := mod.ExportedFunction("pg_initdb").Call(ctx); err != nil {
+		mod.Close(ctx)
+		m.cleanup()
+		return nil, fmt.Errorf("pglite: initdb: %w", err)
+	}
+
+	if _, err := mod.ExportedFunction("use_socketfile").Call(ctx); err != nil {
+		mod.Close(ctx)
+		m.cleanup()
+		return nil, fmt.Errorf("pglite: use_socketfile: %w", err)
+	}
+
+	return &DB{mod: mod, socket: m.socket, snapshotFn: m.snapshot, cleanup: m.cleanup}, nil
+}
+
+// Close shuts down this DB's module instance and cleans up its backend
+// (removing a TempBackend's directory, for example). The package's
+// shared compiled module and runtime stay alive for the next Open.
+func (db *DB) Close(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	modErr := db.mod.Close(ctx)
+	if cleanupErr := db.cleanup(); cleanupErr != nil {
+		return cleanupErr
+	}
+	return modErr
+}
+
+// step writes a simple-query frame, single-steps the module, and returns
+// whatever frames PGlite wrote back.
+func (db *DB) step(ctx context.Context, f frame) ([]frame, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.socket.write(encodeFrame(f)); err != nil {
+		return nil, fmt.Errorf("pglite: write query: %w", err)
+	}
+
+	if _, err := db.mod.ExportedFunction("interactive_one").Call(ctx); err != nil {
+		return nil, fmt.Errorf("pglite: interactive_one: %w", err)
+	}
+
+	raw, err := db.socket.read()
+	if err != nil {
+		return nil, fmt.Errorf("pglite: read response: %w", err)
+	}
+	frames, rest, err := decodeFrames(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("pglite: %d trailing bytes on socket file", len(rest))
+	}
+	return frames, nil
+}
+
+// StepRaw writes raw, already-framed wire-protocol bytes to the socket
+// file, single-steps the module, and returns whatever bytes it wrote
+// back unparsed. It is the primitive pglite/wire uses to proxy an
+// unmodified Postgres client straight through to PGlite.
+func (db *DB) StepRaw(ctx context.Context, in []byte) ([]byte, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.socket.write(in); err != nil {
+		return nil, fmt.Errorf("pglite: write socket: %w", err)
+	}
+
+	if _, err := db.mod.ExportedFunction("interactive_one").Call(ctx); err != nil {
+		return nil, fmt.Errorf("pglite: interactive_one: %w", err)
+	}
+
+	return db.socket.read()
+}
+
+// QueryContext runs sql as a simple-query message and decodes the
+// resulting RowDescription/DataRow frames. It does not accept parameters;
+// use PrepareContext for parameterized queries.
+func (db *DB) QueryContext(ctx context.Context, sql string) (*Rows, error) {
+	frames, err := db.step(ctx, frame{Type: 'Q', Payload: cstring(sql)})
+	if err != nil {
+		return nil, err
+	}
+	result, err := rowsFromFrames(frames)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rows, nil
+}
+
+// ExecContext runs sql and returns the command tag PGlite reports, for
+// statements that don't produce a result set.
+func (db *DB) ExecContext(ctx context.Context, sql string) (*Result, error) {
+	frames, err := db.step(ctx, frame{Type: 'Q', Payload: cstring(sql)})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := rowsFromFrames(frames)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Command: rows.command, RowsAffected: rows.rowsAffected}, nil
+}
+
+func rowsFromFrames(frames []frame) (*rowsAndResult, error) {
+	out := &rowsAndResult{}
+	for _, f := range frames {
+		switch f.Type {
+		case 'T':
+			cols, err := decodeRowDescription(f.Payload)
+			if err != nil {
+				return nil, err
+			}
+			out.Columns = cols
+		case 'D':
+			row, err := decodeDataRow(f.Payload)
+			if err != nil {
+				return nil, err
+			}
+			out.Values = append(out.Values, row)
+		case 'C':
+			out.command, out.rowsAffected = parseCommandComplete(f.Payload)
+		case 'E':
+			return nil, decodeErrorResponse(f.Payload)
+		case 'Z':
+			// ReadyForQuery: end of this statement's frames.
+		}
+	}
+	return out, nil
+}
+
+// rowsAndResult is the union of Rows and Result before we know which of
+// the two callers wanted back.
+type rowsAndResult struct {
+	Rows
+	command      string
+	rowsAffected int64
+}
+
+func parseCommandComplete(payload []byte) (command string, rowsAffected int64) {
+	trimmed := bytes.TrimRight(payload, "\x00")
+	command = string(trimmed)
+	// Where a tag carries a row count it is always the last word
+	// ("INSERT 0 1", "UPDATE 3", "SELECT 2").
+	if fields := bytes.Fields(trimmed); len(fields) > 1 {
+		var n int64
+		if _, err := fmt.Sscanf(string(fields[len(fields)-1]), "%d", &n); err == nil {
+			rowsAffected = n
+		}
+	}
+	return command, rowsAffected
+}
+
+// extractTar is shared with config.go's seedDir, which extracts the
+// embedded base image onto disk for DirBackend/TempBackend.
+func extractTar(tr *tar.Reader, dest string) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+			of, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(of, tr); err != nil {
+				of.Close()
+				return err
+			}
+			of.Close()
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown file type in tar: %c (%s)", header.Typeflag, header.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package pglite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+var (
+	sharedOnce runtimeOnce
+)
+
+// runtimeOnce lazily compiles the embedded postgres.wasi binary exactly
+// once per process, backed by a wazero.CompilationCache on disk so a
+// restart doesn't pay to recompile it either. Every DB instantiates from
+// this one compiled module instead of recompiling it on every Open.
+type runtimeOnce struct {
+	once     sync.Once
+	rt       wazero.Runtime
+	compiled wazero.CompiledModule
+	err      error
+}
+
+func sharedCompiledModule(ctx context.Context) (wazero.Runtime, wazero.CompiledModule, error) {
+	sharedOnce.once.Do(func() {
+		cacheDir := filepath.Join(os.TempDir(), "pglite-compilation-cache")
+		cache, err := wazero.NewCompilationCacheWithDir(cacheDir)
+		if err != nil {
+			sharedOnce.err = fmt.Errorf("compilation cache: %w", err)
+			return
+		}
+
+		rtConfig := wazero.NewRuntimeConfig().WithCompilationCache(cache)
+		rt := wazero.NewRuntimeWithConfig(ctx, rtConfig)
+		if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+			sharedOnce.err = fmt.Errorf("instantiate wasi: %w", err)
+			return
+		}
+
+		blob, err := loadWASIBinary()
+		if err != nil {
+			sharedOnce.err = err
+			return
+		}
+
+		compiled, err := rt.CompileModule(ctx, blob)
+		if err != nil {
+			sharedOnce.err = fmt.Errorf("compile module: %w", err)
+			return
+		}
+
+		sharedOnce.rt = rt
+		sharedOnce.compiled = compiled
+	})
+	return sharedOnce.rt, sharedOnce.compiled, sharedOnce.err
+}
+
+// Pool maintains a fixed number of preinitialized DB instances, each
+// bound to its own data directory, so callers can borrow one without
+// paying compile or initdb costs on every request.
+type Pool struct {
+	mu     sync.Mutex
+	closed bool
+	dbs    chan *DB
+}
+
+// NewPool eagerly opens n DBs, calling newConfig(i) for each one's
+// Config so every member gets its own data directory (e.g. a
+// TempBackend, or a DirBackend whose Path is suffixed with i).
+func NewPool(ctx context.Context, n int, newConfig func(i int) Config) (*Pool, error) {
+	p := &Pool{dbs: make(chan *DB, n)}
+	for i := 0; i < n; i++ {
+		db, err := OpenConfig(ctx, newConfig(i))
+		if err != nil {
+			p.Close(ctx)
+			return nil, fmt.Errorf("pglite: pool: %w", err)
+		}
+		p.dbs <- db
+	}
+	return p, nil
+}
+
+// Acquire returns the next available DB, blocking until one is released
+// or ctx is done.
+func (p *Pool) Acquire(ctx context.Context) (*DB, error) {
+	select {
+	case db := <-p.dbs:
+		return db, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Release returns db to the pool for the next Acquire to reuse. If Close
+// has already run (a caller may still be mid-Acquire/Release when
+// another goroutine tears the pool down), db is closed directly instead
+// of being sent to the now-closed dbs channel.
+func (p *Pool) Release(db *DB) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		db.Close(context.Background())
+		return
+	}
+	p.dbs <- db
+}
+
+// Close shuts down every DB currently in the pool, including any still
+// out on loan that Release later returns. Safe to call concurrently
+// with Acquire/Release; callers don't need to release everything first.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.dbs)
+	p.mu.Unlock()
+
+	var firstErr error
+	for db := range p.dbs {
+		if err := db.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
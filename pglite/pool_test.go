@@ -0,0 +1,79 @@
+package pglite
+
+import (
+	"context"
+	"testing"
+)
+
+// TestSharedCompiledModuleReusedAcrossOpens verifies that consecutive
+// Open calls reuse the same compiled module instead of recompiling the
+// embedded postgres.wasi binary every time.
+func TestSharedCompiledModuleReusedAcrossOpens(t *testing.T) {
+	ctx := context.Background()
+
+	open := func() {
+		db, err := OpenConfig(ctx, Config{FS: TempBackend{}})
+		if err != nil {
+			t.Fatalf("OpenConfig: %v", err)
+		}
+		if err := db.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	open()
+	_, compiled1, err := sharedCompiledModule(ctx)
+	if err != nil {
+		t.Fatalf("sharedCompiledModule: %v", err)
+	}
+	open()
+	_, compiled2, err := sharedCompiledModule(ctx)
+	if err != nil {
+		t.Fatalf("sharedCompiledModule: %v", err)
+	}
+	if compiled1 != compiled2 {
+		t.Fatal("expected the second call to reuse the first call's compiled module")
+	}
+}
+
+// BenchmarkOpen measures per-call Open cost once the compiled module is
+// cached, demonstrating the startup-time improvement the shared
+// compilation cache is meant to buy over recompiling on every call.
+func BenchmarkOpen(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		db, err := OpenConfig(ctx, Config{FS: TempBackend{}})
+		if err != nil {
+			b.Fatalf("OpenConfig: %v", err)
+		}
+		if err := db.Close(ctx); err != nil {
+			b.Fatalf("Close: %v", err)
+		}
+	}
+}
+
+// TestPoolReleaseAfterCloseDoesNotPanic guards against a regression
+// where Release sent unconditionally on the pool's dbs channel: a
+// Release racing a concurrent Close (e.g. a caller that had already
+// Acquired, while another goroutine tears the pool down on context
+// cancellation) would send on a channel Close had already closed and
+// panic.
+func TestPoolReleaseAfterCloseDoesNotPanic(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := NewPool(ctx, 1, func(i int) Config { return Config{FS: TempBackend{}} })
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	db, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	p.Release(db)
+}
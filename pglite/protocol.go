@@ -0,0 +1,467 @@
+package pglite
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Common type OIDs, from Postgres's pg_type catalog, used as hints when
+// binding parameters and as keys when decoding result columns.
+const (
+	OIDBool      uint32 = 16
+	OIDBytea     uint32 = 17
+	OIDInt8      uint32 = 20
+	OIDInt2      uint32 = 21
+	OIDInt4      uint32 = 23
+	OIDText      uint32 = 25
+	OIDFloat4    uint32 = 700
+	OIDFloat8    uint32 = 701
+	OIDTimestamp uint32 = 1114
+)
+
+// Param is one bound parameter: a Go value plus the OID to assume when
+// Describe doesn't tell us what the server actually inferred for this
+// position (see encodeParam).
+type Param struct {
+	oidHint uint32
+	value   any // nil, bool, int64, float64, string, []byte, time.Time, or []Param for an array
+}
+
+// BoolParam, Int4Param, etc. build a Param from a Go value.
+func BoolParam(v bool) Param { return Param{oidHint: OIDBool, value: v} }
+
+func Int4Param(v int32) Param { return Param{oidHint: OIDInt4, value: int64(v)} }
+
+func Int8Param(v int64) Param { return Param{oidHint: OIDInt8, value: v} }
+
+func Float4Param(v float32) Param { return Param{oidHint: OIDFloat4, value: float64(v)} }
+
+func Float8Param(v float64) Param { return Param{oidHint: OIDFloat8, value: v} }
+
+func TextParam(v string) Param { return Param{oidHint: OIDText, value: v} }
+
+func ByteaParam(v []byte) Param { return Param{oidHint: OIDBytea, value: v} }
+
+// pgEpoch is the zero point Postgres's binary timestamp format counts
+// microseconds from.
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TimestampParam(t time.Time) Param { return Param{oidHint: OIDTimestamp, value: t} }
+
+// NullParam is a SQL NULL bound as oid, so PGlite still knows what type
+// to expect if Describe can't tell us either.
+func NullParam(oid uint32) Param {
+	return Param{oidHint: oid}
+}
+
+// arrayOID maps an element OID to its corresponding one-dimensional
+// array OID, for the handful of element types ArrayParam supports.
+func arrayOID(elemOID uint32) uint32 {
+	switch elemOID {
+	case OIDInt4:
+		return 1007
+	case OIDInt8:
+		return 1016
+	case OIDText:
+		return 1009
+	case OIDFloat8:
+		return 1022
+	default:
+		return 0
+	}
+}
+
+// ArrayParam builds a one-dimensional array parameter out of elements
+// already built by the other Param constructors (they must all share the
+// same OID). Arrays are always bound in text format; see encodeParam.
+func ArrayParam(elemOID uint32, elems []Param) Param {
+	return Param{oidHint: arrayOID(elemOID), value: elems}
+}
+
+// Decode converts a row value's raw wire bytes to a Go value, based on
+// its column's OID and format code. A nil raw means SQL NULL, decoded as
+// a nil any.
+func Decode(col Column, raw []byte) (any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if col.FormatCode == 0 {
+		// Text format: PGlite already rendered it the way psql would.
+		return string(raw), nil
+	}
+
+	switch col.TypeOID {
+	case OIDBool:
+		return raw[0] != 0, nil
+	case OIDInt2:
+		return int16(binary.BigEndian.Uint16(raw)), nil
+	case OIDInt4:
+		return int32(binary.BigEndian.Uint32(raw)), nil
+	case OIDInt8:
+		return int64(binary.BigEndian.Uint64(raw)), nil
+	case OIDFloat4:
+		return math.Float32frombits(binary.BigEndian.Uint32(raw)), nil
+	case OIDFloat8:
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+	case OIDTimestamp:
+		micros := int64(binary.BigEndian.Uint64(raw))
+		return pgEpoch.Add(time.Duration(micros) * time.Microsecond), nil
+	case OIDBytea:
+		return raw, nil
+	case OIDText:
+		return string(raw), nil
+	default:
+		return raw, nil
+	}
+}
+
+// Stmt is a prepared statement, parsed once and then bound and executed
+// repeatedly through PGlite's extended query protocol (Parse/Bind/
+// Describe/Execute), rather than the simple-query text path
+// DB.QueryContext/ExecContext use. Callers must call Close when done with
+// it, or the backend accumulates named statements forever.
+type Stmt struct {
+	db        *DB
+	name      string
+	paramOIDs []uint32 // what the server inferred for each $N, from ParameterDescription
+}
+
+// PrepareContext parses sql into a named prepared statement and asks the
+// server (via Describe) what type it inferred for each parameter, so
+// Bind can encode values the way that type actually expects on the wire.
+func (db *DB) PrepareContext(ctx context.Context, name, sql string) (*Stmt, error) {
+	payload := append(cstring(name), cstring(sql)...)
+	payload = append(payload, 0, 0) // no parameter OIDs specified up front; Describe reports what got inferred
+
+	frames, err := db.extendedStep(ctx, []frame{
+		{Type: 'P', Payload: payload},
+		{Type: 'D', Payload: append([]byte{'S'}, cstring(name)...)},
+		{Type: 'S'},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExtendedErrors(frames); err != nil {
+		return nil, err
+	}
+
+	var paramOIDs []uint32
+	for _, f := range frames {
+		if f.Type == 't' {
+			paramOIDs, err = decodeParameterDescription(f.Payload)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Stmt{db: db, name: name, paramOIDs: paramOIDs}, nil
+}
+
+// Close releases this statement on the PGlite backend. Once closed, s
+// must not be used again.
+func (s *Stmt) Close(ctx context.Context) error {
+	frames, err := s.db.extendedStep(ctx, []frame{
+		{Type: 'C', Payload: append([]byte{'S'}, cstring(s.name)...)},
+		{Type: 'S'},
+	})
+	if err != nil {
+		return err
+	}
+	return checkExtendedErrors(frames)
+}
+
+// QueryContext binds params and executes the statement, returning its
+// result set.
+func (s *Stmt) QueryContext(ctx context.Context, params ...Param) (*Rows, error) {
+	frames, err := s.execute(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rowsFromFrames(frames)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rows, nil
+}
+
+// ExecContext binds params and executes the statement, for statements
+// that don't return rows.
+func (s *Stmt) ExecContext(ctx context.Context, params ...Param) (*Result, error) {
+	frames, err := s.execute(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rowsFromFrames(frames)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Command: result.command, RowsAffected: result.rowsAffected}, nil
+}
+
+func (s *Stmt) execute(ctx context.Context, params []Param) ([]frame, error) {
+	bind, err := encodeBind("", s.name, params, s.paramOIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	frames, err := s.db.extendedStep(ctx, []frame{
+		{Type: 'B', Payload: bind},
+		{Type: 'E', Payload: encodeExecute("", 0)},
+		{Type: 'S'},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExtendedErrors(frames); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// encodeBind builds a Bind message payload binding params to portal
+// against statement. paramOIDs is what Describe reported for statement's
+// parameters (nil or short if Describe wasn't called, or inferred fewer
+// types than params supplies); encodeParam falls back to p's own OID hint
+// for any position it doesn't cover.
+func encodeBind(portal, statement string, params []Param, paramOIDs []uint32) ([]byte, error) {
+	type encoded struct {
+		format int16
+		data   []byte // nil means SQL NULL
+	}
+	encodedParams := make([]encoded, len(params))
+	for i, p := range params {
+		var serverOID uint32
+		if i < len(paramOIDs) {
+			serverOID = paramOIDs[i]
+		}
+		format, data, err := encodeParam(p, serverOID)
+		if err != nil {
+			return nil, err
+		}
+		encodedParams[i] = encoded{format, data}
+	}
+
+	buf := append(cstring(portal), cstring(statement)...)
+
+	var u16 [2]byte
+	binary.BigEndian.PutUint16(u16[:], uint16(len(encodedParams)))
+	buf = append(buf, u16[:]...) // number of parameter format codes
+	for _, e := range encodedParams {
+		var fc [2]byte
+		binary.BigEndian.PutUint16(fc[:], uint16(e.format))
+		buf = append(buf, fc[:]...)
+	}
+
+	buf = append(buf, u16[:]...) // number of parameter values
+	var u32 [4]byte
+	for _, e := range encodedParams {
+		if e.data == nil {
+			buf = append(buf, 0xff, 0xff, 0xff, 0xff) // -1 length == NULL
+			continue
+		}
+		binary.BigEndian.PutUint32(u32[:], uint32(len(e.data)))
+		buf = append(buf, u32[:]...)
+		buf = append(buf, e.data...)
+	}
+
+	buf = append(buf, 0, 1, 0, 1) // one result format code, binary, applies to all columns
+	return buf, nil
+}
+
+// encodeParam encodes p for the wire, returning the format code it used
+// (0 text, 1 binary) and the value's bytes (nil for SQL NULL). serverOID
+// is what Describe reported the server actually inferred for this
+// parameter position; p.oidHint is used instead when serverOID is 0
+// (Describe wasn't run, or the server couldn't infer a type).
+//
+// Binding in the wrong binary encoding for whatever OID the server
+// settled on is a hard wire-protocol error (e.g. an 8-byte int8 value
+// sent against a column the parser inferred as int4), so any OID or Go
+// value combination this function doesn't specifically recognize falls
+// back to text format, which Postgres's type input functions can parse
+// regardless of width.
+func encodeParam(p Param, serverOID uint32) (formatCode int16, data []byte, err error) {
+	if p.value == nil {
+		return 1, nil, nil
+	}
+
+	oid := serverOID
+	if oid == 0 {
+		oid = p.oidHint
+	}
+
+	switch oid {
+	case OIDBool:
+		if v, ok := p.value.(bool); ok {
+			b := byte(0)
+			if v {
+				b = 1
+			}
+			return 1, []byte{b}, nil
+		}
+	case OIDInt2:
+		if v, ok := p.value.(int64); ok {
+			var b [2]byte
+			binary.BigEndian.PutUint16(b[:], uint16(v))
+			return 1, b[:], nil
+		}
+	case OIDInt4:
+		if v, ok := p.value.(int64); ok {
+			var b [4]byte
+			binary.BigEndian.PutUint32(b[:], uint32(v))
+			return 1, b[:], nil
+		}
+	case OIDInt8:
+		if v, ok := p.value.(int64); ok {
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], uint64(v))
+			return 1, b[:], nil
+		}
+	case OIDFloat4:
+		if v, ok := p.value.(float64); ok {
+			var b [4]byte
+			binary.BigEndian.PutUint32(b[:], math.Float32bits(float32(v)))
+			return 1, b[:], nil
+		}
+	case OIDFloat8:
+		if v, ok := p.value.(float64); ok {
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+			return 1, b[:], nil
+		}
+	case OIDBytea:
+		if v, ok := p.value.([]byte); ok {
+			return 1, v, nil
+		}
+	case OIDTimestamp:
+		if v, ok := p.value.(time.Time); ok {
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], uint64(v.UTC().Sub(pgEpoch).Microseconds()))
+			return 1, b[:], nil
+		}
+	}
+
+	text, err := paramText(p.value)
+	if err != nil {
+		return 0, nil, err
+	}
+	return 0, []byte(text), nil
+}
+
+// paramText renders a bound value the way Postgres's text-format type
+// input functions expect to parse it.
+func paramText(value any) (string, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "t", nil
+		}
+		return "f", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case string:
+		return v, nil
+	case []byte:
+		return "\\x" + hex.EncodeToString(v), nil
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999Z07:00"), nil
+	case []Param:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			if e.value == nil {
+				elems[i] = "NULL"
+				continue
+			}
+			text, err := paramText(e.value)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = text
+		}
+		return "{" + strings.Join(elems, ",") + "}", nil
+	default:
+		return "", fmt.Errorf("pglite: unsupported parameter type %T", value)
+	}
+}
+
+// encodeExecute builds an Execute message payload for portal, stopping
+// after maxRows rows (0 means no limit).
+func encodeExecute(portal string, maxRows int32) []byte {
+	buf := cstring(portal)
+	var u32 [4]byte
+	binary.BigEndian.PutUint32(u32[:], uint32(maxRows))
+	return append(buf, u32[:]...)
+}
+
+// decodeParameterDescription parses a 't' frame payload (ParameterDescription)
+// into the OID the server inferred for each parameter, in order.
+func decodeParameterDescription(payload []byte) ([]uint32, error) {
+	if len(payload) < 2 {
+		return nil, fmt.Errorf("pglite: short ParameterDescription")
+	}
+	n := binary.BigEndian.Uint16(payload[0:2])
+	oids := make([]uint32, n)
+	buf := payload[2:]
+	for i := range oids {
+		if len(buf) < 4 {
+			return nil, fmt.Errorf("pglite: truncated ParameterDescription")
+		}
+		oids[i] = binary.BigEndian.Uint32(buf[0:4])
+		buf = buf[4:]
+	}
+	return oids, nil
+}
+
+// extendedStep writes several already-framed messages in one batch,
+// single-steps the module, and returns whatever frames it wrote back.
+// Batching Parse/Describe/Bind/Execute/Sync together mirrors how a real
+// client pipelines the extended protocol.
+func (db *DB) extendedStep(ctx context.Context, frames []frame) ([]frame, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var buf []byte
+	for _, f := range frames {
+		buf = append(buf, encodeFrame(f)...)
+	}
+	if err := db.socket.write(buf); err != nil {
+		return nil, fmt.Errorf("pglite: write extended query: %w", err)
+	}
+
+	if _, err := db.mod.ExportedFunction("interactive_one").Call(ctx); err != nil {
+		return nil, fmt.Errorf("pglite: interactive_one: %w", err)
+	}
+
+	raw, err := db.socket.read()
+	if err != nil {
+		return nil, fmt.Errorf("pglite: read response: %w", err)
+	}
+	out, rest, err := decodeFrames(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("pglite: %d trailing bytes on socket file", len(rest))
+	}
+	return out, nil
+}
+
+// checkExtendedErrors returns the first ErrorResponse frame found, if
+// any; ParseComplete/BindComplete/etc. carry no payload worth checking.
+func checkExtendedErrors(frames []frame) error {
+	for _, f := range frames {
+		if f.Type == 'E' {
+			return decodeErrorResponse(f.Payload)
+		}
+	}
+	return nil
+}
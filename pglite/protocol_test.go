@@ -0,0 +1,230 @@
+package pglite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestEncodeParamUsesBinaryForKnownOIDs(t *testing.T) {
+	format, data, err := encodeParam(Int4Param(42), 0)
+	if err != nil {
+		t.Fatalf("encodeParam: %v", err)
+	}
+	if format != 1 {
+		t.Errorf("format = %d, want 1 (binary)", format)
+	}
+	if got := int32(binary.BigEndian.Uint32(data)); got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+}
+
+func TestEncodeParamServerOIDOverridesHint(t *testing.T) {
+	// Hint says int8, but the server inferred int4 for this position;
+	// serverOID should win.
+	p := Int8Param(7)
+	format, data, err := encodeParam(p, OIDInt4)
+	if err != nil {
+		t.Fatalf("encodeParam: %v", err)
+	}
+	if format != 1 || len(data) != 4 {
+		t.Fatalf("format=%d data=%v, want 4-byte binary int4", format, data)
+	}
+}
+
+func TestEncodeParamNull(t *testing.T) {
+	format, data, err := encodeParam(NullParam(OIDText), 0)
+	if err != nil {
+		t.Fatalf("encodeParam: %v", err)
+	}
+	if format != 1 || data != nil {
+		t.Errorf("format=%d data=%v, want binary format with nil data", format, data)
+	}
+}
+
+func TestEncodeParamFallsBackToTextForUnrecognizedCombination(t *testing.T) {
+	// A bool value bound against an OID encodeParam doesn't special-case
+	// for bool falls back to text rather than erroring.
+	format, data, err := encodeParam(BoolParam(true), OIDTimestamp)
+	if err != nil {
+		t.Fatalf("encodeParam: %v", err)
+	}
+	if format != 0 {
+		t.Errorf("format = %d, want 0 (text)", format)
+	}
+	if string(data) != "t" {
+		t.Errorf("data = %q, want \"t\"", data)
+	}
+}
+
+func TestParamText(t *testing.T) {
+	cases := []struct {
+		value any
+		want  string
+	}{
+		{true, "t"},
+		{false, "f"},
+		{int64(-5), "-5"},
+		{float64(1.5), "1.5"},
+		{"hi", "hi"},
+		{[]byte{0xde, 0xad}, "\\xdead"},
+	}
+	for _, c := range cases {
+		got, err := paramText(c.value)
+		if err != nil {
+			t.Fatalf("paramText(%v): %v", c.value, err)
+		}
+		if got != c.want {
+			t.Errorf("paramText(%v) = %q, want %q", c.value, got, c.want)
+		}
+	}
+}
+
+func TestParamTextArray(t *testing.T) {
+	got, err := paramText([]Param{TextParam("a"), NullParam(OIDText), TextParam("b")})
+	if err != nil {
+		t.Fatalf("paramText: %v", err)
+	}
+	if got != "{a,NULL,b}" {
+		t.Errorf("got %q, want {a,NULL,b}", got)
+	}
+}
+
+func TestParamTextRejectsUnsupportedType(t *testing.T) {
+	if _, err := paramText(struct{}{}); err == nil {
+		t.Fatal("expected an error for an unsupported Go type")
+	}
+}
+
+func TestDecodeParameterDescription(t *testing.T) {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, 2)
+	var oid [4]byte
+	binary.BigEndian.PutUint32(oid[:], OIDInt4)
+	buf = append(buf, oid[:]...)
+	binary.BigEndian.PutUint32(oid[:], OIDText)
+	buf = append(buf, oid[:]...)
+
+	oids, err := decodeParameterDescription(buf)
+	if err != nil {
+		t.Fatalf("decodeParameterDescription: %v", err)
+	}
+	if len(oids) != 2 || oids[0] != OIDInt4 || oids[1] != OIDText {
+		t.Errorf("oids = %v", oids)
+	}
+}
+
+func TestDecodeParameterDescriptionRejectsTruncated(t *testing.T) {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, 1) // claims one OID, supplies none
+	if _, err := decodeParameterDescription(buf); err == nil {
+		t.Fatal("expected an error for a truncated ParameterDescription")
+	}
+}
+
+func TestEncodeBind(t *testing.T) {
+	params := []Param{Int4Param(1), TextParam("hi")}
+	buf, err := encodeBind("", "stmt1", params, []uint32{OIDInt4, OIDText})
+	if err != nil {
+		t.Fatalf("encodeBind: %v", err)
+	}
+
+	// portal (empty cstring), statement name cstring, then format/value counts.
+	want := []byte{0}
+	want = append(want, cstring("stmt1")...)
+	if !bytes.HasPrefix(buf, want) {
+		t.Fatalf("buf = %v, want prefix %v", buf, want)
+	}
+	// Last 4 bytes select one binary result format code for all columns.
+	tail := buf[len(buf)-4:]
+	if !bytes.Equal(tail, []byte{0, 1, 0, 1}) {
+		t.Errorf("tail = %v, want [0 1 0 1]", tail)
+	}
+}
+
+func TestDecodeRoundTripsKnownOIDs(t *testing.T) {
+	cases := []struct {
+		name string
+		col  Column
+		raw  []byte
+		want any
+	}{
+		{"bool", Column{TypeOID: OIDBool, FormatCode: 1}, []byte{1}, true},
+		{"int2", Column{TypeOID: OIDInt2, FormatCode: 1}, mustBinary(int16(5)), int16(5)},
+		{"int4", Column{TypeOID: OIDInt4, FormatCode: 1}, mustBinary(int32(5)), int32(5)},
+		{"int8", Column{TypeOID: OIDInt8, FormatCode: 1}, mustBinary(int64(5)), int64(5)},
+		{"bytea", Column{TypeOID: OIDBytea, FormatCode: 1}, []byte{0xab}, []byte{0xab}},
+	}
+	for _, c := range cases {
+		got, err := Decode(c.col, c.raw)
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", c.name, err)
+		}
+		if !equalAny(got, c.want) {
+			t.Errorf("%s: got %#v, want %#v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDecodeNullIsNilRegardlessOfType(t *testing.T) {
+	got, err := Decode(Column{TypeOID: OIDInt4, FormatCode: 1}, nil)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestDecodeTextFormatReturnsString(t *testing.T) {
+	got, err := Decode(Column{TypeOID: OIDInt4, FormatCode: 0}, []byte("42"))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got != "42" {
+		t.Errorf("got %#v, want \"42\"", got)
+	}
+}
+
+func TestDecodeTimestamp(t *testing.T) {
+	want := pgEpoch.Add(5 * time.Second)
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(5*time.Second/time.Microsecond))
+
+	got, err := Decode(Column{TypeOID: OIDTimestamp, FormatCode: 1}, b[:])
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	ts, ok := got.(time.Time)
+	if !ok || !ts.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func mustBinary(v any) []byte {
+	switch x := v.(type) {
+	case int16:
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(x))
+		return b[:]
+	case int32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(x))
+		return b[:]
+	case int64:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(x))
+		return b[:]
+	default:
+		panic("unsupported")
+	}
+}
+
+func equalAny(a, b any) bool {
+	if ab, ok := a.([]byte); ok {
+		bb, ok := b.([]byte)
+		return ok && bytes.Equal(ab, bb)
+	}
+	return a == b
+}
@@ -0,0 +1,140 @@
+package pglite
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Column describes one field of a result set, as reported by PGlite's
+// RowDescription message.
+type Column struct {
+	Name       string
+	TableOID   uint32
+	Number     int16
+	TypeOID    uint32
+	TypeSize   int16
+	TypeMod    int32
+	FormatCode int16
+}
+
+// Rows is the result of a query: column metadata plus every row's raw
+// column values, still in wire format (NULL is represented by a nil
+// []byte, matching the protocol's -1 length marker).
+type Rows struct {
+	Columns []Column
+	Values  [][][]byte
+}
+
+// Result is the outcome of a statement that doesn't return rows, mirroring
+// database/sql/driver.Result.
+type Result struct {
+	Command      string
+	RowsAffected int64
+}
+
+// decodeRowDescription parses a 'T' frame payload into column metadata.
+func decodeRowDescription(payload []byte) ([]Column, error) {
+	if len(payload) < 2 {
+		return nil, fmt.Errorf("pglite: short RowDescription")
+	}
+	n := binary.BigEndian.Uint16(payload[0:2])
+	cols := make([]Column, 0, n)
+	buf := payload[2:]
+	for i := uint16(0); i < n; i++ {
+		nul := indexByte(buf, 0)
+		if nul < 0 {
+			return nil, fmt.Errorf("pglite: malformed RowDescription field name")
+		}
+		name := string(buf[:nul])
+		buf = buf[nul+1:]
+		if len(buf) < 18 {
+			return nil, fmt.Errorf("pglite: truncated RowDescription")
+		}
+		cols = append(cols, Column{
+			Name:       name,
+			TableOID:   binary.BigEndian.Uint32(buf[0:4]),
+			Number:     int16(binary.BigEndian.Uint16(buf[4:6])),
+			TypeOID:    binary.BigEndian.Uint32(buf[6:10]),
+			TypeSize:   int16(binary.BigEndian.Uint16(buf[10:12])),
+			TypeMod:    int32(binary.BigEndian.Uint32(buf[12:16])),
+			FormatCode: int16(binary.BigEndian.Uint16(buf[16:18])),
+		})
+		buf = buf[18:]
+	}
+	return cols, nil
+}
+
+// decodeDataRow parses a 'D' frame payload into one row's raw column
+// values.
+func decodeDataRow(payload []byte) ([][]byte, error) {
+	if len(payload) < 2 {
+		return nil, fmt.Errorf("pglite: short DataRow")
+	}
+	n := binary.BigEndian.Uint16(payload[0:2])
+	row := make([][]byte, 0, n)
+	buf := payload[2:]
+	for i := uint16(0); i < n; i++ {
+		if len(buf) < 4 {
+			return nil, fmt.Errorf("pglite: truncated DataRow")
+		}
+		length := int32(binary.BigEndian.Uint32(buf[0:4]))
+		buf = buf[4:]
+		if length < 0 {
+			row = append(row, nil)
+			continue
+		}
+		if int(length) > len(buf) {
+			return nil, fmt.Errorf("pglite: truncated DataRow value")
+		}
+		row = append(row, buf[:length])
+		buf = buf[length:]
+	}
+	return row, nil
+}
+
+// decodeErrorResponse turns an 'E' frame payload into a *pq-style *Error
+// holding its severity, SQLSTATE code, message and detail fields. Other
+// field codes are dropped.
+func decodeErrorResponse(payload []byte) error {
+	fields := map[byte]string{}
+	for len(payload) > 0 && payload[0] != 0 {
+		code := payload[0]
+		nul := indexByte(payload[1:], 0)
+		if nul < 0 {
+			break
+		}
+		fields[code] = string(payload[1 : 1+nul])
+		payload = payload[1+nul+1:]
+	}
+	return &Error{
+		Severity: fields['S'],
+		Code:     fields['C'],
+		Message:  fields['M'],
+		Detail:   fields['D'],
+	}
+}
+
+func indexByte(b []byte, c byte) int {
+	for i, v := range b {
+		if v == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// Error is a Postgres error response, shaped to mirror the fields
+// github.com/lib/pq's *pq.Error exposes.
+type Error struct {
+	Severity string
+	Code     string
+	Message  string
+	Detail   string
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("pglite: %s: %s (%s)", e.Severity, e.Message, e.Code)
+	}
+	return fmt.Sprintf("pglite: %s", e.Message)
+}
@@ -0,0 +1,106 @@
+package pglite
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func buildRowDescriptionPayload(names []string) []byte {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, uint16(len(names)))
+	for _, name := range names {
+		buf = append(buf, cstring(name)...)
+		buf = append(buf, make([]byte, 18)...) // tableOID, number, typeOID, typeSize, typeMod, formatCode
+	}
+	return buf
+}
+
+func TestDecodeRowDescription(t *testing.T) {
+	payload := buildRowDescriptionPayload([]string{"id", "name"})
+	cols, err := decodeRowDescription(payload)
+	if err != nil {
+		t.Fatalf("decodeRowDescription: %v", err)
+	}
+	if len(cols) != 2 {
+		t.Fatalf("got %d columns, want 2", len(cols))
+	}
+	if cols[0].Name != "id" || cols[1].Name != "name" {
+		t.Errorf("cols = %+v", cols)
+	}
+}
+
+func TestDecodeRowDescriptionRejectsShortPayload(t *testing.T) {
+	if _, err := decodeRowDescription([]byte{0}); err == nil {
+		t.Fatal("expected an error for a payload shorter than the count field")
+	}
+}
+
+func TestDecodeRowDescriptionRejectsTruncatedField(t *testing.T) {
+	payload := append([]byte{0, 1}, cstring("id")...) // missing the 18 metadata bytes
+	if _, err := decodeRowDescription(payload); err == nil {
+		t.Fatal("expected an error for a truncated field")
+	}
+}
+
+func buildDataRowPayload(values [][]byte) []byte {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, uint16(len(values)))
+	for _, v := range values {
+		if v == nil {
+			buf = append(buf, 0xff, 0xff, 0xff, 0xff)
+			continue
+		}
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(v)))
+		buf = append(buf, length[:]...)
+		buf = append(buf, v...)
+	}
+	return buf
+}
+
+func TestDecodeDataRow(t *testing.T) {
+	payload := buildDataRowPayload([][]byte{[]byte("1"), nil, []byte("hi")})
+	row, err := decodeDataRow(payload)
+	if err != nil {
+		t.Fatalf("decodeDataRow: %v", err)
+	}
+	if len(row) != 3 {
+		t.Fatalf("got %d values, want 3", len(row))
+	}
+	if string(row[0]) != "1" || row[1] != nil || string(row[2]) != "hi" {
+		t.Errorf("row = %v", row)
+	}
+}
+
+func TestDecodeDataRowRejectsTruncatedValue(t *testing.T) {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, 1)
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], 10)
+	buf = append(buf, length[:]...)
+	buf = append(buf, []byte("short")...) // claims 10 bytes, only has 5
+
+	if _, err := decodeDataRow(buf); err == nil {
+		t.Fatal("expected an error for a value longer than the remaining payload")
+	}
+}
+
+func TestDecodeErrorResponse(t *testing.T) {
+	var payload []byte
+	payload = append(payload, 'S')
+	payload = append(payload, cstring("ERROR")...)
+	payload = append(payload, 'C')
+	payload = append(payload, cstring("23505")...)
+	payload = append(payload, 'M')
+	payload = append(payload, cstring("duplicate key")...)
+	payload = append(payload, 0)
+
+	err := decodeErrorResponse(payload)
+	pgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("got %T, want *Error", err)
+	}
+	if pgErr.Severity != "ERROR" || pgErr.Code != "23505" || pgErr.Message != "duplicate key" {
+		t.Errorf("pgErr = %+v", pgErr)
+	}
+}
@@ -0,0 +1,229 @@
+package pglite
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sgosiaco/pglite-go/pglite/memfs"
+)
+
+// Snapshot writes the current data directory as a gzipped tar to w, so it
+// can be restored later with Restore or OpenFromSnapshot. It holds db's
+// internal lock for the full directory walk and gzip, the same as any
+// other call into db, so every other query blocks until Snapshot
+// returns; callers snapshotting a large data directory should expect
+// that pause rather than concurrent access to db.
+func (db *DB) Snapshot(ctx context.Context, w io.Writer) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Not every PGlite build exports fsync; best effort only.
+	if fsync := db.mod.ExportedFunction("fsync"); fsync != nil {
+		_, _ = fsync.Call(ctx)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := db.snapshotFn(tw); err != nil {
+		tw.Close()
+		gw.Close()
+		return fmt.Errorf("pglite: snapshot: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("pglite: snapshot: %w", err)
+	}
+	return gw.Close()
+}
+
+// writeDirTar is DirBackend/TempBackend's snapshot func: it walks a real
+// directory on disk into tw.
+func writeDirTar(tw *tar.Writer, dir string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(tw, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeMemFSTar is MemoryBackend's snapshot func: it walks an in-memory
+// filesystem into tw, so an ephemeral database can still be snapshotted.
+func writeMemFSTar(tw *tar.Writer, mem *memfs.FS) error {
+	return mem.Walk(func(name string, mode fs.FileMode, data []byte) error {
+		header := &tar.Header{
+			Name: name,
+			Mode: int64(mode.Perm()),
+			Size: int64(len(data)),
+		}
+		if mode.IsDir() {
+			header.Typeflag = tar.TypeDir
+			header.Size = 0
+		} else {
+			header.Typeflag = tar.TypeReg
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !mode.IsDir() {
+			_, err := tw.Write(data)
+			return err
+		}
+		return nil
+	})
+}
+
+// Restore extracts a gzipped tar produced by Snapshot into dir, which is
+// created if necessary. It rejects archive entries that would escape dir
+// (via "..", absolute paths, or symlinks pointing outside the root).
+func Restore(r io.Reader, dir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("pglite: restore: %w", err)
+	}
+	defer gr.Close()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("pglite: restore: %w", err)
+	}
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("pglite: restore: %w", err)
+		}
+
+		target, err := safeJoin(dir, header.Name)
+		if err != nil {
+			return fmt.Errorf("pglite: restore: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			of, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(of, tr); err != nil {
+				of.Close()
+				return err
+			}
+			of.Close()
+		case tar.TypeSymlink:
+			// filepath.Join treats an absolute Linkname as just another
+			// path segment, so an unchecked "/etc/passwd" would pass
+			// safeJoin's relative-escape check below yet still end up
+			// passed straight to os.Symlink, creating a link that points
+			// outside dir entirely. Reject that case explicitly first.
+			if filepath.IsAbs(header.Linkname) {
+				return fmt.Errorf("pglite: restore: symlink %q has an absolute target %q", header.Name, header.Linkname)
+			}
+			if _, err := safeJoin(dir, filepath.Join(filepath.Dir(header.Name), header.Linkname)); err != nil {
+				return fmt.Errorf("pglite: restore: symlink %q escapes root: %w", header.Name, err)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("pglite: restore: unknown file type in tar: %c (%s)", header.Typeflag, header.Name)
+		}
+	}
+}
+
+// OpenFromSnapshot restores r into a fresh temp directory and boots a
+// PGlite instance against it. Like TempBackend, the directory is removed
+// again once the returned *DB is Closed.
+func OpenFromSnapshot(ctx context.Context, r io.Reader) (*DB, error) {
+	dir, err := os.MkdirTemp("", "pglite-snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("pglite: %w", err)
+	}
+	if err := Restore(r, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	db, err := OpenConfig(ctx, Config{FS: DirBackend{Path: dir}})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	innerCleanup := db.cleanup
+	db.cleanup = func() error {
+		cleanupErr := innerCleanup()
+		if rmErr := os.RemoveAll(dir); cleanupErr == nil {
+			cleanupErr = rmErr
+		}
+		return cleanupErr
+	}
+	return db, nil
+}
+
+// safeJoin joins dir and name, and errors if the result would resolve
+// outside of dir.
+func safeJoin(dir, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("entry %q has an absolute path", name)
+	}
+	target := filepath.Join(dir, name)
+	relCheck, err := filepath.Rel(dir, target)
+	if err != nil || relCheck == ".." || strings.HasPrefix(relCheck, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("entry %q escapes %q", name, dir)
+	}
+	return target, nil
+}
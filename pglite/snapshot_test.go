@@ -0,0 +1,159 @@
+package pglite
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	dir := "/data"
+	cases := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"foo/bar.txt", false},
+		{"../escape.txt", true},
+		{"/etc/passwd", true},
+		{"foo/../../escape.txt", true},
+	}
+	for _, c := range cases {
+		_, err := safeJoin(dir, c.name)
+		if (err != nil) != c.wantErr {
+			t.Errorf("safeJoin(%q, %q): err = %v, wantErr = %v", dir, c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestRestoreRejectsAbsoluteSymlinkTarget(t *testing.T) {
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "evil",
+		Typeflag: tar.TypeSymlink,
+		Linkname: "/etc/passwd",
+		Mode:     0o777,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gw.Close()
+
+	if err := Restore(&buf, dir); err == nil {
+		t.Fatal("expected Restore to reject an absolute symlink target")
+	}
+	if _, statErr := os.Lstat(filepath.Join(dir, "evil")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no symlink to have been created, got stat err = %v", statErr)
+	}
+}
+
+func TestRestoreRejectsPathEscapingEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "../escape.txt",
+		Typeflag: tar.TypeReg,
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gw.Close()
+
+	if err := Restore(&buf, dir); err == nil {
+		t.Fatal("expected Restore to reject an entry escaping dir")
+	}
+}
+
+func TestRestoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	content := []byte("hello")
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "base/PG_VERSION",
+		Typeflag: tar.TypeReg,
+		Mode:     0o644,
+		Size:     int64(len(content)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gw.Close()
+
+	if err := Restore(&buf, dir); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "base/PG_VERSION"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+// TestOpenFromSnapshotRemovesTempDirOnClose guards against a regression
+// where OpenFromSnapshot's restore directory was mounted as a plain
+// DirBackend (a no-op cleanup), leaking the temp directory on every
+// Close instead of removing it the way TempBackend does.
+func TestOpenFromSnapshotRemovesTempDirOnClose(t *testing.T) {
+	ctx := context.Background()
+
+	src, err := OpenConfig(ctx, Config{FS: TempBackend{}})
+	if err != nil {
+		t.Fatalf("OpenConfig: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := src.Snapshot(ctx, &buf); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if err := src.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	before, err := filepath.Glob(filepath.Join(os.TempDir(), "pglite-snapshot-*"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+
+	db, err := OpenFromSnapshot(ctx, &buf)
+	if err != nil {
+		t.Fatalf("OpenFromSnapshot: %v", err)
+	}
+
+	after, err := filepath.Glob(filepath.Join(os.TempDir(), "pglite-snapshot-*"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(after) != len(before)+1 {
+		t.Fatalf("expected OpenFromSnapshot to create exactly one new restore dir, before = %v, after = %v", before, after)
+	}
+
+	if err := db.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	final, err := filepath.Glob(filepath.Join(os.TempDir(), "pglite-snapshot-*"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(final) != len(before) {
+		t.Fatalf("expected Close to remove the restore dir, before = %v, final = %v", before, final)
+	}
+}
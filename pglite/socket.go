@@ -0,0 +1,77 @@
+package pglite
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/sgosiaco/pglite-go/pglite/memfs"
+)
+
+// socketIO reads and writes the raw bytes of PGlite's wire-protocol
+// socket file, regardless of whether /tmp lives on disk or in memory.
+type socketIO interface {
+	write(b []byte) error
+	read() ([]byte, error)
+}
+
+// diskSocket backs the socket file with a real file on disk, for
+// DirBackend and TempBackend.
+type diskSocket struct {
+	path string
+}
+
+func (s diskSocket) write(b []byte) error {
+	return os.WriteFile(s.path, b, 0o644)
+}
+
+func (s diskSocket) read() ([]byte, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := os.WriteFile(s.path, nil, 0o644); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// memSocket backs the socket file with an in-memory file, for
+// MemoryBackend.
+type memSocket struct {
+	mem  *memfs.FS
+	name string
+}
+
+func (s memSocket) write(b []byte) error {
+	f, err := s.mem.OpenFile(s.name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.(io.Writer).Write(b)
+	return err
+}
+
+func (s memSocket) read() ([]byte, error) {
+	f, err := s.mem.Open(s.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(f.(io.Reader))
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(nil); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,219 @@
+// Package wire exposes a PGlite *pglite.DB over a real net.Listener, so
+// unmodified clients (psql, pgx, lib/pq, ...) can speak the Postgres wire
+// protocol to it as if it were a normal server, instead of linking
+// against this module directly.
+package wire
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/sgosiaco/pglite-go/pglite"
+)
+
+// sslRequestCode is the special startup code clients send to ask whether
+// the server supports SSL, before the real StartupMessage.
+const sslRequestCode = 80877103
+
+// Listener accepts Postgres wire connections and pumps bytes between each
+// one and db's socket file. PGlite has exactly one backend session with
+// no notion of per-connection state (no isolated transactions, no
+// per-connection search_path), so unlike a real Postgres server this
+// Listener cannot let two connections be live at once: a second client
+// would execute inside whatever transaction or session state the first
+// one left behind. sessionMu enforces that only one connection at a
+// time owns db; every other connection is refused outright (the TCP
+// connection is closed with no bytes written) until the active one
+// disconnects.
+type Listener struct {
+	db *pglite.DB
+	ln net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+
+	sessionMu sync.Mutex
+}
+
+// Listen opens a TCP listener on addr in front of db.
+func Listen(addr string, db *pglite.DB) (*Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("pglite/wire: listen: %w", err)
+	}
+	return &Listener{db: db, ln: ln, conns: make(map[net.Conn]struct{})}, nil
+}
+
+// Addr returns the address the listener is bound to.
+func (l *Listener) Addr() net.Addr { return l.ln.Addr() }
+
+// Close stops accepting new connections.
+func (l *Listener) Close() error { return l.ln.Close() }
+
+// Serve accepts connections until ctx is canceled or Close is called. On
+// cancellation it also closes every in-flight connection, so handle's
+// blocking conn.Read calls unblock instead of waiting for clients to
+// disconnect on their own.
+func (l *Listener) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		l.ln.Close()
+		l.closeConns()
+	}()
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			wg.Wait()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		l.trackConn(conn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer l.untrackConn(conn)
+			l.handle(ctx, conn)
+		}()
+	}
+}
+
+func (l *Listener) trackConn(conn net.Conn) {
+	l.mu.Lock()
+	l.conns[conn] = struct{}{}
+	l.mu.Unlock()
+}
+
+func (l *Listener) untrackConn(conn net.Conn) {
+	l.mu.Lock()
+	delete(l.conns, conn)
+	l.mu.Unlock()
+}
+
+// closeConns force-closes every connection currently tracked, so Serve
+// can return promptly on cancellation instead of waiting for clients to
+// disconnect on their own.
+func (l *Listener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for conn := range l.conns {
+		conn.Close()
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	// Only one connection may drive db's single PGlite session at a
+	// time; see the Listener doc comment. Refuse everyone else rather
+	// than silently interleaving their queries into the active session.
+	if !l.sessionMu.TryLock() {
+		return
+	}
+	defer l.sessionMu.Unlock()
+
+	if err := l.negotiate(ctx, conn); err != nil {
+		return
+	}
+
+	for {
+		msg, err := readClientMessage(conn)
+		if err != nil {
+			return
+		}
+
+		out, err := l.db.StepRaw(ctx, msg)
+		if err != nil {
+			return
+		}
+		if len(out) == 0 {
+			continue
+		}
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+// negotiate rejects SSLRequest (PGlite speaks plaintext only) and passes
+// the real StartupMessage straight through to PGlite, relaying whatever
+// it answers with (AuthenticationOk, ParameterStatus, ReadyForQuery, ...).
+func (l *Listener) negotiate(ctx context.Context, conn net.Conn) error {
+	for {
+		payload, code, err := readStartupPacket(conn)
+		if err != nil {
+			return err
+		}
+		if code == sslRequestCode {
+			if _, err := conn.Write([]byte{'N'}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		out, err := l.db.StepRaw(ctx, payload)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(out)
+		return err
+	}
+}
+
+// readStartupPacket reads a length-prefixed, untyped startup packet
+// (used for both SSLRequest and StartupMessage) and returns its raw
+// bytes (length prefix included, as PGlite expects to see it) plus the
+// protocol/request code that follows the length.
+func readStartupPacket(conn net.Conn) (raw []byte, code uint32, err error) {
+	var lenBuf [4]byte
+	if _, err := readFull(conn, lenBuf[:]); err != nil {
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(lenBuf[:])
+	if length < 8 {
+		return nil, 0, fmt.Errorf("pglite/wire: short startup packet")
+	}
+	rest := make([]byte, length-4)
+	if _, err := readFull(conn, rest); err != nil {
+		return nil, 0, err
+	}
+	code = binary.BigEndian.Uint32(rest[:4])
+	return append(lenBuf[:], rest...), code, nil
+}
+
+// readClientMessage reads one typed, length-prefixed message (type byte
+// plus big-endian length) the way PGlite expects to find it framed on
+// the socket file.
+func readClientMessage(conn net.Conn) ([]byte, error) {
+	var header [5]byte
+	if _, err := readFull(conn, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:5])
+	if length < 4 {
+		return nil, fmt.Errorf("pglite/wire: invalid message length %d", length)
+	}
+	body := make([]byte, length-4)
+	if _, err := readFull(conn, body); err != nil {
+		return nil, err
+	}
+	return append(header[:], body...), nil
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
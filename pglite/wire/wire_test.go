@@ -0,0 +1,156 @@
+package wire
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadStartupPacket(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	payload := append([]byte{0, 0, 0, 0}, 3, 0, 0, 0)
+	binary.BigEndian.PutUint32(payload[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(payload[4:8], 196608) // protocol 3.0
+
+	go client.Write(payload)
+
+	raw, code, err := readStartupPacket(server)
+	if err != nil {
+		t.Fatalf("readStartupPacket: %v", err)
+	}
+	if code != 196608 {
+		t.Errorf("code = %d, want 196608", code)
+	}
+	if string(raw) != string(payload) {
+		t.Errorf("raw = %v, want %v", raw, payload)
+	}
+}
+
+func TestReadStartupPacketRejectsShortPacket(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], 4)
+	go client.Write(lenBuf[:])
+
+	if _, _, err := readStartupPacket(server); err == nil {
+		t.Fatal("expected an error for a startup packet shorter than 8 bytes")
+	}
+}
+
+func TestReadClientMessage(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	body := []byte("SELECT 1")
+	msg := make([]byte, 5+len(body))
+	msg[0] = 'Q'
+	binary.BigEndian.PutUint32(msg[1:5], uint32(4+len(body)))
+	copy(msg[5:], body)
+
+	go client.Write(msg)
+
+	got, err := readClientMessage(server)
+	if err != nil {
+		t.Fatalf("readClientMessage: %v", err)
+	}
+	if string(got) != string(msg) {
+		t.Errorf("got %v, want %v", got, msg)
+	}
+}
+
+func TestReadClientMessageRejectsInvalidLength(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	header := []byte{'Q', 0, 0, 0, 0}
+	go client.Write(header)
+
+	if _, err := readClientMessage(server); err == nil {
+		t.Fatal("expected an error for a message length < 4")
+	}
+}
+
+func TestReadFullStopsOnError(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	go func() {
+		client.Write([]byte{1, 2})
+		client.Close()
+	}()
+
+	buf := make([]byte, 5)
+	n, err := readFull(server, buf)
+	if err == nil {
+		t.Fatal("expected an error once the peer closes early")
+	}
+	if n != 2 {
+		t.Errorf("n = %d, want 2", n)
+	}
+}
+
+func TestReadFullReadsAcrossMultipleWrites(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		client.Write([]byte{1, 2})
+		time.Sleep(time.Millisecond)
+		client.Write([]byte{3, 4})
+	}()
+
+	buf := make([]byte, 4)
+	n, err := readFull(server, buf)
+	if err != nil {
+		t.Fatalf("readFull: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("n = %d, want 4", n)
+	}
+	if string(buf) != string([]byte{1, 2, 3, 4}) {
+		t.Errorf("buf = %v, want [1 2 3 4]", buf)
+	}
+}
+
+// TestHandleRefusesSecondConnection guards against interleaving two
+// live connections on PGlite's one backend session: while sessionMu is
+// held (standing in for an in-progress connection), handle must refuse
+// a second connection outright rather than proceeding to negotiate
+// against the shared db.
+func TestHandleRefusesSecondConnection(t *testing.T) {
+	l := &Listener{conns: make(map[net.Conn]struct{})}
+	l.sessionMu.Lock()
+	defer l.sessionMu.Unlock()
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		l.handle(context.Background(), server)
+		close(done)
+	}()
+
+	buf := make([]byte, 1)
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := client.Read(buf); err == nil {
+		t.Fatal("expected the refused connection to be closed with no bytes written")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handle did not return promptly for a refused connection")
+	}
+}